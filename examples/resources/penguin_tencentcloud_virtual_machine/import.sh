@@ -0,0 +1,6 @@
+# Virtual machines can be imported by the UUID returned from the Penguin create endpoint.
+terraform import penguin_tencentcloud_virtual_machine.example 7f1f1e97-7f45-4c4b-94b4-2f1c248a8b1e
+
+# The status endpoint does not report name, security_group, vpc_id and subnet_id. Append them
+# to the identifier so that `terraform plan -generate-config-out` writes a complete configuration.
+terraform import penguin_tencentcloud_virtual_machine.example '7f1f1e97-7f45-4c4b-94b4-2f1c248a8b1e,name=web-1,security_group=sg-5hilszwp,vpc_id=vpc-oahbq6lh,subnet_id=subnet-95tfs6am'
@@ -10,4 +10,6 @@ resource "penguin_tencentcloud_virtual_machine" "example" {
 
   bandwidth_limit_mbps = 20
   total_transfer_kb    = 1048576
+
+  power_state = "running"
 }
@@ -0,0 +1,9 @@
+resource "penguin_tencentcloud_virtual_machine_password" "example" {
+  virtual_machine_id = penguin_tencentcloud_virtual_machine.example.id
+  rotation_days      = 90
+  force_stop         = true
+
+  keepers = {
+    rotation = "2025-q1"
+  }
+}
@@ -0,0 +1,5 @@
+resource "penguin_tencentcloud_virtual_machine_renewal" "example" {
+  virtual_machine_id = penguin_tencentcloud_virtual_machine.example.id
+  period_months      = 1
+  renew_before_days  = 7
+}
@@ -0,0 +1,8 @@
+resource "penguin_tencentcloud_virtual_machine_transfer_reset" "monthly" {
+  virtual_machine_id = penguin_tencentcloud_virtual_machine.example.id
+  start_if_suspended = true
+
+  triggers = {
+    billing_cycle = "2025-01"
+  }
+}
@@ -6,8 +6,10 @@ package penguin
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 )
 
 func (c *Client) Health(ctx context.Context) error {
@@ -22,7 +24,47 @@ func (c *Client) InternalHealth(ctx context.Context) (*InternalHealthResponse, e
 	return &out, nil
 }
 
+// ListZones returns the zones of the default deployment and, with per-region endpoints, of every
+// regional deployment, without duplicates.
 func (c *Client) ListZones(ctx context.Context) ([]Zone, error) {
+	zones, err := cached(ctx, c.cache, cacheKeyZones, func(ctx context.Context) ([]Zone, error) {
+		if c.regions == nil {
+			return c.listZones(ctx)
+		}
+
+		var zones []Zone
+		seen := map[string]bool{}
+		add := func(list []Zone) {
+			for _, z := range list {
+				if !seen[z.Zone] {
+					seen[z.Zone] = true
+					zones = append(zones, z)
+				}
+			}
+		}
+		if len(c.endpoints) > 0 {
+			list, err := c.listZones(ctx)
+			if err != nil {
+				return nil, err
+			}
+			add(list)
+		}
+		for _, region := range slices.Sorted(maps.Keys(c.regions.clients)) {
+			list, err := c.regions.clients[region].listZones(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("list zones of region %q: %w", region, err)
+			}
+			add(list)
+		}
+		return zones, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(zones), nil
+}
+
+func (c *Client) listZones(ctx context.Context) ([]Zone, error) {
 	var out ZonesResponse
 	if err := c.doJSON(ctx, http.MethodGet, "/tencentcloud/zones", nil, nil, &out, http.StatusOK); err != nil {
 		return nil, err
@@ -30,41 +72,127 @@ func (c *Client) ListZones(ctx context.Context) ([]Zone, error) {
 	return out.Zones, nil
 }
 
+// InternalMetrics fetches the Prometheus metrics of the default deployment.
+func (c *Client) InternalMetrics(ctx context.Context) (*InternalMetricsResponse, error) {
+	var raw []byte
+	if err := c.doJSON(ctx, http.MethodGet, "/_internal/metrics", nil, nil, &raw, http.StatusOK); err != nil {
+		return nil, err
+	}
+	samples, err := parsePrometheusText(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode metrics: %w", err)
+	}
+	return &InternalMetricsResponse{Raw: string(raw), Samples: samples}, nil
+}
+
 func (c *Client) SelectBandwidthPackage(ctx context.Context, region string, networkType string) (*BandwidthPackageSelectionResponse, error) {
+	ctx = WithRegion(ctx, region)
 	query := url.Values{}
 	query.Set("region", region)
 	if networkType != "" {
 		query.Set("networkType", networkType)
 	}
 
-	var out BandwidthPackageSelectionResponse
-	if err := c.doJSON(ctx, http.MethodGet, "/tencentcloud/bandwidth-packages", query, nil, &out, http.StatusOK); err != nil {
+	out, err := cached(ctx, c.cache, cacheKeyBandwidthPackages+query.Encode(), func(ctx context.Context) (BandwidthPackageSelectionResponse, error) {
+		var out BandwidthPackageSelectionResponse
+		err := c.doJSON(ctx, http.MethodGet, "/tencentcloud/bandwidth-packages", query, nil, &out, http.StatusOK)
+		return out, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetImageByName looks up an image by name in region, routed to the deployment serving region.
+func (c *Client) GetImageByName(ctx context.Context, region string, name string) (*Image, error) {
+	query := url.Values{}
+	query.Set("region", region)
+	var out Image
+	if err := c.doJSON(WithRegion(ctx, region), http.MethodGet, "/tencentcloud/images/"+pathSegment(name), query, nil, &out, http.StatusOK); err != nil {
 		return nil, err
 	}
 	return &out, nil
 }
 
+// ListMissingVirtualMachines returns the virtual machines whose Tencent Cloud instances are gone,
+// from the default deployment and, with per-region endpoints, every regional deployment.
+func (c *Client) ListMissingVirtualMachines(ctx context.Context) ([]MissingVirtualMachine, error) {
+	if c.regions == nil {
+		return c.listMissingVirtualMachines(ctx)
+	}
+
+	var out []MissingVirtualMachine
+	if len(c.endpoints) > 0 {
+		list, err := c.listMissingVirtualMachines(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, list...)
+	}
+	for _, region := range slices.Sorted(maps.Keys(c.regions.clients)) {
+		list, err := c.regions.clients[region].listMissingVirtualMachines(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list missing virtual machines of region %q: %w", region, err)
+		}
+		for _, vm := range list {
+			c.rememberVirtualMachineRegion(vm.ID, region)
+		}
+		out = append(out, list...)
+	}
+	return out, nil
+}
+
+func (c *Client) listMissingVirtualMachines(ctx context.Context) ([]MissingVirtualMachine, error) {
+	var out []MissingVirtualMachine
+	if err := c.doJSON(ctx, http.MethodGet, "/tencentcloud/vms/missing", nil, nil, &out, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *Client) CreateVirtualMachine(ctx context.Context, req CreateVirtualMachineRequest) (*CreateVirtualMachineResponse, error) {
+	defer c.cache.invalidate(cacheKeyBandwidthPackages)
+	region := RegionFromZone(req.Zone)
 	var out CreateVirtualMachineResponse
-	if err := c.doJSON(ctx, http.MethodPost, "/tencentcloud/vms", nil, req, &out, http.StatusCreated); err != nil {
+	if err := c.doJSON(WithRegion(ctx, region), http.MethodPost, "/tencentcloud/vms", nil, req, &out, http.StatusCreated); err != nil {
 		return nil, err
 	}
+	c.rememberVirtualMachineRegion(out.ID, region)
 	return &out, nil
 }
 
 func (c *Client) DeleteVirtualMachine(ctx context.Context, id string) error {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer c.cache.invalidate(cacheKeyVMStatus + id)
+	defer c.cache.invalidate(cacheKeyBandwidthPackages)
 	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/tencentcloud/vms/%s", url.PathEscape(id)), nil, nil, nil, http.StatusAccepted)
 }
 
 func (c *Client) GetVirtualMachineStatus(ctx context.Context, id string) (*VirtualMachineStatus, error) {
-	var out VirtualMachineStatus
-	if err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/tencentcloud/vms/%s/status", url.PathEscape(id)), nil, nil, &out, http.StatusOK); err != nil {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out, err := cached(ctx, c.cache, cacheKeyVMStatus+id, func(ctx context.Context) (VirtualMachineStatus, error) {
+		var out VirtualMachineStatus
+		err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/tencentcloud/vms/%s/status", url.PathEscape(id)), nil, nil, &out, http.StatusOK)
+		return out, err
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &out, nil
 }
 
 func (c *Client) GetVirtualMachineMetrics(ctx context.Context, id string, r string) (*VirtualMachineMetricsResponse, error) {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 	query := url.Values{}
 	if r != "" {
 		query.Set("range", r)
@@ -77,6 +205,10 @@ func (c *Client) GetVirtualMachineMetrics(ctx context.Context, id string, r stri
 }
 
 func (c *Client) GetVirtualMachineVNC(ctx context.Context, id string) (*VirtualMachineVNCResponse, error) {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 	var out VirtualMachineVNCResponse
 	if err := c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/tencentcloud/vms/%s/vnc", url.PathEscape(id)), nil, nil, &out, http.StatusOK); err != nil {
 		return nil, err
@@ -85,11 +217,39 @@ func (c *Client) GetVirtualMachineVNC(ctx context.Context, id string) (*VirtualM
 }
 
 func (c *Client) AdjustVirtualMachineBandwidth(ctx context.Context, id string, bandwidthLimitMbps int64) error {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer c.cache.invalidate(cacheKeyVMStatus + id)
 	req := AdjustBandwidthRequest{BandwidthLimitMbps: bandwidthLimitMbps}
-	return c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/tencentcloud/vms/%s/bandwidth", url.PathEscape(id)), nil, req, nil, http.StatusAccepted)
+	return c.doJSON(withRetrySafe(ctx), http.MethodPost, fmt.Sprintf("/tencentcloud/vms/%s/bandwidth", url.PathEscape(id)), nil, req, nil, http.StatusAccepted)
+}
+
+func (c *Client) StartVirtualMachine(ctx context.Context, id string) error {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer c.cache.invalidate(cacheKeyVMStatus + id)
+	return c.doJSON(withRetrySafe(ctx), http.MethodPost, fmt.Sprintf("/tencentcloud/vms/%s/start", url.PathEscape(id)), nil, nil, nil, http.StatusAccepted)
+}
+
+func (c *Client) ShutdownVirtualMachine(ctx context.Context, id string) error {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer c.cache.invalidate(cacheKeyVMStatus + id)
+	return c.doJSON(withRetrySafe(ctx), http.MethodPost, fmt.Sprintf("/tencentcloud/vms/%s/shutdown", url.PathEscape(id)), nil, nil, nil, http.StatusAccepted)
 }
 
 func (c *Client) RenewVirtualMachine(ctx context.Context, id string, req RenewVirtualMachineRequest) (*RenewVirtualMachineResponse, error) {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer c.cache.invalidate(cacheKeyVMStatus + id)
 	var out RenewVirtualMachineResponse
 	if err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/tencentcloud/vms/%s/renew", url.PathEscape(id)), nil, req, &out, http.StatusOK); err != nil {
 		return nil, err
@@ -98,10 +258,20 @@ func (c *Client) RenewVirtualMachine(ctx context.Context, id string, req RenewVi
 }
 
 func (c *Client) ReinstallVirtualMachine(ctx context.Context, id string, req ReinstallVirtualMachineRequest) error {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer c.cache.invalidate(cacheKeyVMStatus + id)
 	return c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/tencentcloud/vms/%s/reinstall", url.PathEscape(id)), nil, req, nil, http.StatusAccepted)
 }
 
 func (c *Client) ResetVirtualMachinePassword(ctx context.Context, id string, req ResetVirtualMachinePasswordRequest) (*ResetVirtualMachinePasswordResponse, error) {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer c.cache.invalidate(cacheKeyVMStatus + id)
 	var out ResetVirtualMachinePasswordResponse
 	if err := c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/tencentcloud/vms/%s/reset-password", url.PathEscape(id)), nil, req, &out, http.StatusOK); err != nil {
 		return nil, err
@@ -110,10 +280,17 @@ func (c *Client) ResetVirtualMachinePassword(ctx context.Context, id string, req
 }
 
 func (c *Client) ResetVirtualMachineTransfer(ctx context.Context, id string) error {
+	ctx, err := c.withVirtualMachineRegion(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer c.cache.invalidate(cacheKeyVMStatus + id)
 	return c.doJSON(ctx, http.MethodPost, fmt.Sprintf("/tencentcloud/vms/%s/reset-transfer", url.PathEscape(id)), nil, nil, nil, http.StatusNoContent)
 }
 
 func (c *Client) CreateElasticIP(ctx context.Context, req CreateElasticIPRequest) (*CreateElasticIPResponse, error) {
+	ctx = WithRegion(ctx, req.Region)
+	defer c.cache.invalidate(cacheKeyBandwidthPackages)
 	var out CreateElasticIPResponse
 	if err := c.doJSON(ctx, http.MethodPost, "/tencentcloud/eips", nil, req, &out, http.StatusCreated); err != nil {
 		return nil, err
@@ -122,6 +299,8 @@ func (c *Client) CreateElasticIP(ctx context.Context, req CreateElasticIPRequest
 }
 
 func (c *Client) DeleteElasticIP(ctx context.Context, region string, id string) error {
+	ctx = WithRegion(ctx, region)
+	defer c.cache.invalidate(cacheKeyBandwidthPackages)
 	query := url.Values{}
 	query.Set("region", region)
 	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/tencentcloud/eips/%s", url.PathEscape(id)), query, nil, nil, http.StatusNoContent)
@@ -129,7 +308,7 @@ func (c *Client) DeleteElasticIP(ctx context.Context, region string, id string)
 
 func (c *Client) IssueJWT(ctx context.Context, req IssueJWTRequest) (*IssueJWTResponse, error) {
 	var out IssueJWTResponse
-	if err := c.doJSON(ctx, http.MethodPost, "/auth/jwt", nil, req, &out, http.StatusCreated); err != nil {
+	if err := c.doJSON(withRetrySafe(ctx), http.MethodPost, "/auth/jwt", nil, req, &out, http.StatusCreated); err != nil {
 		return nil, err
 	}
 	return &out, nil
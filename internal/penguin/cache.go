@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTL is how long the provider keeps read-only responses. A plan refreshes every
+// resource within a few seconds, so this is enough to deduplicate reads of the same VM.
+const DefaultCacheTTL = 5 * time.Second
+
+const (
+	cacheKeyZones             = "zones"
+	cacheKeyBandwidthPackages = "bandwidth-packages/"
+	cacheKeyVMStatus          = "vm-status/"
+)
+
+// responseCache coalesces concurrent identical reads and keeps their results for a short TTL.
+// Mutating calls invalidate the keys they affect and bump the generation, which keeps any read
+// that was already in flight from storing its possibly stale result.
+type responseCache struct {
+	ttl   time.Duration
+	group singleflight.Group
+
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	generation uint64
+}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// cached returns the cached value for key or calls fetch, sharing one call between concurrent
+// callers. Requests made with a WithoutCache context always fetch but still refresh the entry.
+// A shared fetch ignores the cancellation of the caller that started it, so that caller giving up
+// does not fail the others; each caller still stops waiting when its own ctx is done.
+func cached[T any](ctx context.Context, c *responseCache, key string, fetch func(context.Context) (T, error)) (T, error) {
+	if c.ttl <= 0 {
+		return fetch(ctx)
+	}
+
+	if !isCacheBypassed(ctx) {
+		if v, ok := c.get(key); ok {
+			if value, ok := v.(T); ok {
+				return value, nil
+			}
+		}
+	}
+
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	if isCacheBypassed(ctx) {
+		v, err := fetch(ctx)
+		if err == nil {
+			c.put(key, v, generation)
+		}
+		return v, err
+	}
+
+	shared := context.WithoutCancel(ctx)
+	ch := c.group.DoChan(key, func() (any, error) {
+		v, err := fetch(shared)
+		if err == nil {
+			c.put(key, v, generation)
+		}
+		return v, err
+	})
+
+	select {
+	case res := <-ch:
+		value, _ := res.Val.(T)
+		return value, res.Err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func (c *responseCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *responseCache) put(key string, value any, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	c.entries[key] = cacheEntry{value: value, expires: time.Now().Add(c.ttl)}
+}
+
+// invalidate drops the entry for key. A key ending in "/" names a group, e.g.
+// cacheKeyBandwidthPackages, and drops every entry under it; otherwise only the exact key goes,
+// so invalidating one VM status keeps the cached status of a VM whose ID merely extends it.
+func (c *responseCache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if !strings.HasSuffix(key, "/") {
+		delete(c.entries, key)
+		return
+	}
+	for k := range c.entries {
+		if strings.HasPrefix(k, key) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+type cacheBypassKey struct{}
+
+// WithoutCache makes reads with ctx skip cached responses, e.g. while polling for a state change.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func isCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
@@ -7,14 +7,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"path"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -23,34 +24,92 @@ const (
 )
 
 type Client struct {
-	baseURL    *url.URL
-	httpClient *http.Client
+	// endpoints are the replicas in order of preference. It is only empty for a client that
+	// serves every request from regions.
+	endpoints  []*serviceEndpoint
+	regions    *regionRouter
 	authHeader string
-	userAgent  string
+	// credentials, when set, replaces authHeader with tokens fetched per request.
+	credentials CredentialSource
+	userAgent   string
+	retry       RetryPolicy
+	limiter     *requestLimiter
+	cache       *responseCache
+	har         *harRecorder
+	tracer      trace.Tracer
 }
 
 type ClientOptions struct {
 	HTTPClient *http.Client
 	UserAgent  string
+	// TLS configures certificate verification and client certificates. It is only applied to
+	// the default HTTP client, not to a custom HTTPClient. Unix socket endpoints
+	// (unix:///path.sock or http+unix://%2Fpath.sock/base) are likewise dialed by the default
+	// HTTP client only.
+	TLS *TLSOptions
+	// Retry controls retries of transient failures. The zero value makes a single attempt.
+	Retry RetryPolicy
+	// RequestsPerSecond caps the request rate across all callers; zero means unlimited.
+	RequestsPerSecond float64
+	// MaxConcurrentRequests caps requests in flight across all callers; zero means unlimited.
+	MaxConcurrentRequests int
+	// CacheTTL keeps zone, bandwidth package and VM status responses for this long and
+	// coalesces identical concurrent reads; zero disables caching.
+	CacheTTL time.Duration
+	// TraceFile, when set, records every request/response pair with secrets redacted as a
+	// HAR 1.2 file at this path.
+	TraceFile string
+	// FailoverEndpoints are further replicas of the endpoint passed to NewClient, in order of
+	// preference. Requests go to the first healthy endpoint; after a connection error, 502 or 503
+	// an endpoint is ejected for a while and requests that are safe to repeat are retried on the
+	// next one. Other requests, such as creating a virtual machine, are never replayed.
+	FailoverEndpoints []string
+	// Regions maps Tencent Cloud regions to separate Penguin deployments. Requests for a region,
+	// derived from the zone, region or virtual machine involved, go to its deployment; other
+	// requests go to the endpoint passed to NewClient, which may then be empty.
+	Regions map[string]RegionEndpoint
+	// Credentials supplies tokens that can change during the client's lifetime. When set, the
+	// legacyToken and jwt arguments of NewClient are ignored and a request rejected with 401 is
+	// repeated once with refreshed credentials.
+	Credentials CredentialSource
+	// TracerProvider receives a client span per API call; defaults to the global OpenTelemetry
+	// provider, which is a no-op unless one is installed.
+	TracerProvider trace.TracerProvider
 }
 
 func NewClient(endpoint string, legacyToken string, jwt string, opts ClientOptions) (*Client, error) {
-	if strings.TrimSpace(endpoint) == "" {
-		return nil, errors.New("endpoint is required")
+	var sharedHTTPClient *http.Client
+	shared := func() (*http.Client, error) {
+		if sharedHTTPClient != nil {
+			return sharedHTTPClient, nil
+		}
+		if opts.TLS == nil {
+			sharedHTTPClient = &http.Client{Timeout: defaultTimeout}
+			return sharedHTTPClient, nil
+		}
+		httpClient, err := newTLSHTTPClient(opts.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("configure TLS: %w", err)
+		}
+		sharedHTTPClient = httpClient
+		return sharedHTTPClient, nil
 	}
 
-	parsed, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("parse endpoint: %w", err)
-	}
-	if parsed.Scheme == "" || parsed.Host == "" {
-		return nil, fmt.Errorf("endpoint must include scheme and host, got %q", endpoint)
+	var endpoints []*serviceEndpoint
+	if strings.TrimSpace(endpoint) != "" || len(opts.Regions) == 0 {
+		for _, raw := range append([]string{endpoint}, opts.FailoverEndpoints...) {
+			e, err := newEndpoint(raw, opts, shared)
+			if err != nil {
+				return nil, err
+			}
+			endpoints = append(endpoints, e)
+		}
 	}
-	parsed.Path = strings.TrimRight(parsed.Path, "/")
 
-	httpClient := opts.HTTPClient
-	if httpClient == nil {
-		httpClient = &http.Client{Timeout: defaultTimeout}
+	limiter := newRequestLimiter(opts.RequestsPerSecond, opts.MaxConcurrentRequests)
+	regions, err := newRegionRouter(opts.Regions, opts, legacyToken, jwt, limiter)
+	if err != nil {
+		return nil, err
 	}
 
 	userAgent := strings.TrimSpace(opts.UserAgent)
@@ -58,11 +117,25 @@ func NewClient(endpoint string, legacyToken string, jwt string, opts ClientOptio
 		userAgent = "terraform-provider-penguin"
 	}
 
+	var har *harRecorder
+	if opts.TraceFile != "" {
+		har, err = openHARRecorder(opts.TraceFile, userAgent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &Client{
-		baseURL:    parsed,
-		httpClient: httpClient,
-		authHeader: buildAuthHeader(legacyToken, jwt),
-		userAgent:  userAgent,
+		endpoints:   endpoints,
+		regions:     regions,
+		authHeader:  buildAuthHeader(legacyToken, jwt),
+		credentials: opts.Credentials,
+		userAgent:   userAgent,
+		retry:       opts.Retry.withDefaults(),
+		limiter:     limiter,
+		cache:       newResponseCache(opts.CacheTTL),
+		har:         har,
+		tracer:      newTracer(opts.TracerProvider),
 	}, nil
 }
 
@@ -81,25 +154,126 @@ func buildAuthHeader(legacyToken string, jwt string) string {
 	return "Bearer " + jwt
 }
 
-func (c *Client) urlFor(p string) string {
-	clone := *c.baseURL
-	clone.Path = path.Join(c.baseURL.Path, p)
-	return clone.String()
-}
+func (c *Client) doJSON(ctx context.Context, method string, p string, query url.Values, in any, out any, okStatuses ...int) (err error) {
+	target, err := c.regionClient(ctx)
+	if err != nil {
+		return err
+	}
+	if target != c {
+		return target.doJSON(ctx, method, p, query, in, out, okStatuses...)
+	}
 
-func (c *Client) doJSON(ctx context.Context, method string, p string, query url.Values, in any, out any, okStatuses ...int) error {
-	var body io.Reader
+	ctx = withLogSubsystem(ctx)
+	ctx, span := c.tracer.Start(ctx, method+" "+spanRoute(p),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.path", p),
+		),
+	)
+	attempts := 0
+	defer func() { endSpan(span, attempts, err) }()
+
+	var payload []byte
 	if in != nil {
-		payload, err := json.Marshal(in)
+		var err error
+		payload, err = json.Marshal(in)
 		if err != nil {
 			return fmt.Errorf("marshal request: %w", err)
 		}
+	}
+
+	// Health checks of a pinned endpoint report its state directly instead of retrying.
+	retryable := (isIdempotentMethod(method) || isRetrySafe(ctx)) && pinnedEndpoint(ctx) == nil
+	var (
+		retries       int
+		refreshAuth   bool
+		authRefreshed bool
+		tried         = map[*serviceEndpoint]bool{}
+	)
+	ep := c.selectEndpoint(ctx, nil)
+	for {
+		attempts++
+		authHeader, err := c.authorization(ctx, refreshAuth)
+		if err != nil {
+			return err
+		}
+		refreshAuth = false
+
+		status, header, respBytes, err := c.send(ctx, ep, method, p, query, payload, authHeader)
+		if status != 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+		}
+		c.recordEndpointResult(ctx, ep, status, err)
+		// The service rejected the request before acting on it, so repeating it with refreshed
+		// credentials is safe for any method. This does not count as a retry.
+		if err == nil && status == http.StatusUnauthorized && c.credentials != nil && !authRefreshed {
+			refreshAuth, authRefreshed = true, true
+			span.AddEvent("refresh credentials")
+			continue
+		}
+		if err == nil && !isRetryableStatus(status) {
+			if len(okStatuses) == 0 {
+				okStatuses = []int{http.StatusOK}
+			}
+			if !statusIn(status, okStatuses) {
+				return parseAPIError(method, p, status, respBytes)
+			}
+			return decodeResponse(respBytes, out)
+		}
+		failover := err != nil || isFailoverStatus(status)
+		if err == nil {
+			err = parseAPIError(method, p, status, respBytes)
+		}
+
+		if !retryable || ctx.Err() != nil {
+			return err
+		}
+
+		// Move on to the next replica right away; only requests that are safe to repeat get here.
+		if failover {
+			tried[ep] = true
+			if next := c.selectEndpoint(ctx, tried); next != nil {
+				span.AddEvent("failover", trace.WithAttributes(
+					attribute.String("penguin.endpoint.from", ep.String()),
+					attribute.String("penguin.endpoint.to", next.String()),
+				))
+				ep = next
+				continue
+			}
+		}
+
+		if retries >= c.retry.MaxRetries {
+			return err
+		}
+		wait := c.retry.backoff(retries, status, header)
+		retries++
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("penguin.attempt", attempts),
+			attribute.Int64("penguin.retry_wait_ms", wait.Milliseconds()),
+		))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		clear(tried)
+		ep = c.selectEndpoint(ctx, nil)
+	}
+}
+
+// send performs a single HTTP round trip and returns the status, headers and (size limited) body.
+func (c *Client) send(ctx context.Context, ep *serviceEndpoint, method string, p string, query url.Values, payload []byte, authHeader string) (int, http.Header, []byte, error) {
+	var body io.Reader
+	if payload != nil {
 		body = bytes.NewReader(payload)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.urlFor(p), body)
+	req, err := http.NewRequestWithContext(ctx, method, ep.urlFor(p), body)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return 0, nil, nil, fmt.Errorf("build request: %w", err)
 	}
 
 	if len(query) > 0 {
@@ -108,35 +282,61 @@ func (c *Client) doJSON(ctx context.Context, method string, p string, query url.
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", c.userAgent)
-	if in != nil {
+	if payload != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
-	if c.authHeader != "" {
-		req.Header.Set("Authorization", c.authHeader)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	injectTraceContext(req)
+	if requestID := newRequestID(); requestID != "" {
+		req.Header.Set(requestIDHeader, requestID)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	release, err := c.limiter.acquire(ctx)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return 0, nil, nil, fmt.Errorf("wait for request slot: %w", err)
+	}
+	defer release()
+
+	logRequest(ctx, req, payload)
+	start := time.Now()
+
+	resp, err := ep.httpClient.Do(req)
+	if err != nil {
+		logTransportError(ctx, req, err, time.Since(start))
+		c.recordTrace(req, payload, nil, nil, start, err)
+		return 0, nil, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	reader := io.LimitReader(resp.Body, maxResponseBytes)
 	respBytes, readErr := io.ReadAll(reader)
 	if readErr != nil {
-		return fmt.Errorf("read response: %w", readErr)
+		logTransportError(ctx, req, readErr, time.Since(start))
+		c.recordTrace(req, payload, resp, nil, start, readErr)
+		return 0, nil, nil, fmt.Errorf("read response: %w", readErr)
 	}
+	logResponse(ctx, req, resp, respBytes, time.Since(start))
+	c.recordTrace(req, payload, resp, respBytes, start, nil)
+	return resp.StatusCode, resp.Header, respBytes, nil
+}
 
-	if len(okStatuses) == 0 {
-		okStatuses = []int{http.StatusOK}
-	}
-	if !statusIn(resp.StatusCode, okStatuses) {
-		return parseAPIError(resp.StatusCode, respBytes)
+func (c *Client) recordTrace(req *http.Request, payload []byte, resp *http.Response, respBytes []byte, start time.Time, err error) {
+	if c.har != nil {
+		c.har.record(req, payload, resp, respBytes, start, time.Since(start), err)
 	}
+}
 
+func decodeResponse(respBytes []byte, out any) error {
 	if out == nil {
 		return nil
 	}
+	if raw, ok := out.(*[]byte); ok {
+		*raw = respBytes
+		return nil
+	}
 	if len(respBytes) == 0 {
 		return nil
 	}
@@ -156,21 +356,25 @@ func statusIn(got int, allowed []int) bool {
 	return false
 }
 
-func parseAPIError(status int, respBytes []byte) error {
+func parseAPIError(method string, p string, status int, respBytes []byte) error {
 	var apiErr APIError
 	if len(respBytes) > 0 && json.Unmarshal(respBytes, &apiErr) == nil && apiErr.Message != "" {
 		if apiErr.Status == 0 {
 			apiErr.Status = status
 		}
-		return &apiErr
+	} else {
+		msg := strings.TrimSpace(string(respBytes))
+		if msg == "" {
+			msg = http.StatusText(status)
+		}
+		apiErr = APIError{
+			Status:  status,
+			Message: msg,
+		}
 	}
 
-	msg := strings.TrimSpace(string(respBytes))
-	if msg == "" {
-		msg = http.StatusText(status)
-	}
-	return &APIError{
-		Status:  status,
-		Message: msg,
-	}
+	apiErr.Method = method
+	apiErr.Path = p
+	apiErr.Body = respBytes
+	return &apiErr
 }
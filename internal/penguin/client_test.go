@@ -6,10 +6,33 @@ package penguin
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -168,3 +191,1103 @@ func TestClient_SelectBandwidthPackage(t *testing.T) {
 		t.Fatalf("unexpected response: %#v", out)
 	}
 }
+
+func TestClient_StartVirtualMachineConflict(t *testing.T) {
+	t.Parallel()
+
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method != http.MethodPost || r.URL.Path != "/tencentcloud/vms/vm-1/start" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		return &http.Response{
+			StatusCode: http.StatusConflict,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(`{"status":409,"message":"transfer quota exceeded"}`)),
+		}, nil
+	})
+
+	client, err := NewClient("http://example.com", "", "", ClientOptions{
+		HTTPClient: &http.Client{Transport: transport},
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	err = client.StartVirtualMachine(context.Background(), "vm-1")
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected APIError, got %T (%v)", err, err)
+	}
+	if apiErr.Status != http.StatusConflict {
+		t.Fatalf("unexpected api error: %#v", apiErr)
+	}
+}
+
+func TestClient_RetriesTransientFailures(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{"Retry-After": []string{"0"}},
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":503,"message":"busy"}`)),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(`{"id":"bwp-123","availableCount":1}`)),
+		}, nil
+	})
+
+	client, err := NewClient("http://example.com", "", "", ClientOptions{
+		HTTPClient: &http.Client{Transport: transport},
+		Retry:      RetryPolicy{MaxRetries: 2, MinWait: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	out, err := client.SelectBandwidthPackage(context.Background(), "ap-guangzhou", "")
+	if err != nil {
+		t.Fatalf("SelectBandwidthPackage error: %v", err)
+	}
+	if calls != 2 || out.ID != "bwp-123" {
+		t.Fatalf("unexpected result after %d calls: %#v", calls, out)
+	}
+}
+
+func TestClient_DoesNotRetryUnsafePost(t *testing.T) {
+	t.Parallel()
+
+	for name, call := range map[string]func(*Client) error{
+		"create": func(c *Client) error {
+			_, err := c.CreateVirtualMachine(context.Background(), CreateVirtualMachineRequest{Name: "vm"})
+			return err
+		},
+		// Every reset writes an audit row and zeroes usage, so it is not idempotent.
+		"reset transfer": func(c *Client) error {
+			return c.ResetVirtualMachineTransfer(context.Background(), "vm-1")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				calls++
+				return &http.Response{
+					StatusCode: http.StatusBadGateway,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"status":502,"message":"upstream failed"}`)),
+				}, nil
+			})
+
+			client, err := NewClient("http://example.com", "", "", ClientOptions{
+				HTTPClient: &http.Client{Transport: transport},
+				Retry:      RetryPolicy{MaxRetries: 2, MinWait: time.Millisecond},
+			})
+			if err != nil {
+				t.Fatalf("NewClient error: %v", err)
+			}
+
+			err = call(client)
+			apiErr, ok := err.(*APIError)
+			if !ok || apiErr.Status != http.StatusBadGateway {
+				t.Fatalf("expected 502 APIError, got %T (%v)", err, err)
+			}
+			if calls != 1 {
+				t.Fatalf("expected a single attempt, got %d", calls)
+			}
+		})
+	}
+}
+
+func TestClient_ErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	body := `{"status":503,"message":"no schedulable bandwidth package available"}`
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}, nil
+	})
+
+	client, err := NewClient("http://example.com", "", "", ClientOptions{
+		HTTPClient: &http.Client{Transport: transport},
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	_, err = client.SelectBandwidthPackage(context.Background(), "ap-guangzhou", "")
+	wrapped := fmt.Errorf("select: %w", err)
+	if !errors.Is(wrapped, ErrNoBandwidthPackage) {
+		t.Fatalf("expected ErrNoBandwidthPackage, got %v", err)
+	}
+	if errors.Is(wrapped, ErrNotFound) || errors.Is(wrapped, ErrUpstream) {
+		t.Fatalf("unexpected classification of %v", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(wrapped, &apiErr) {
+		t.Fatalf("expected APIError, got %T (%v)", err, err)
+	}
+	if apiErr.Method != http.MethodGet || apiErr.Path != "/tencentcloud/bandwidth-packages" || string(apiErr.Body) != body {
+		t.Fatalf("unexpected api error: %#v", apiErr)
+	}
+}
+
+func TestAPIError_Upstream(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		err  *APIError
+		want bool
+	}{
+		{&APIError{Status: http.StatusBadGateway, Path: "/tencentcloud/vms"}, true},
+		{&APIError{Status: http.StatusInternalServerError, Path: "/tencentcloud/vms/vm-1/start"}, true},
+		{&APIError{Status: http.StatusInternalServerError, Path: "/tencentcloud/vms/vm-1/reset-transfer"}, false},
+		{&APIError{Status: http.StatusInternalServerError, Path: "/auth/jwt"}, false},
+		{&APIError{Status: http.StatusServiceUnavailable, Path: "/tencentcloud/vms/vm-1/start"}, false},
+	} {
+		if got := errors.Is(tc.err, ErrUpstream); got != tc.want {
+			t.Errorf("errors.Is(%d %s, ErrUpstream) = %v, want %v", tc.err.Status, tc.err.Path, got, tc.want)
+		}
+	}
+}
+
+func TestRequestLimiter_ReservesSlotForForegroundRequests(t *testing.T) {
+	t.Parallel()
+
+	limiter := newRequestLimiter(0, 2)
+	ctx := context.Background()
+
+	releasePoll, err := limiter.acquire(WithLowPriority(ctx))
+	if err != nil {
+		t.Fatalf("acquire error: %v", err)
+	}
+
+	blocked, cancel := context.WithTimeout(WithLowPriority(ctx), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(blocked); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected second poll to wait for a slot, got %v", err)
+	}
+
+	releaseCreate, err := limiter.acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected foreground request to get the reserved slot, got %v", err)
+	}
+	releaseCreate()
+	releasePoll()
+}
+
+func TestClient_CachesStatusUntilMutation(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	statusCalls := 0
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/tencentcloud/vms/vm-1/status":
+			mu.Lock()
+			statusCalls++
+			mu.Unlock()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(bytes.NewBufferString(`{"id":"vm-1","instanceState":"RUNNING"}`)),
+			}, nil
+		case r.Method == http.MethodPost && r.URL.Path == "/tencentcloud/vms/vm-1/shutdown":
+			return &http.Response{StatusCode: http.StatusAccepted, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		}
+		t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		return nil, nil
+	})
+
+	client, err := NewClient("http://example.com", "", "", ClientOptions{
+		HTTPClient: &http.Client{Transport: transport},
+		CacheTTL:   time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetVirtualMachineStatus(ctx, "vm-1"); err != nil {
+				t.Errorf("GetVirtualMachineStatus error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if statusCalls != 1 {
+		t.Fatalf("expected one status request, got %d", statusCalls)
+	}
+
+	if err := client.ShutdownVirtualMachine(ctx, "vm-1"); err != nil {
+		t.Fatalf("ShutdownVirtualMachine error: %v", err)
+	}
+	if _, err := client.GetVirtualMachineStatus(ctx, "vm-1"); err != nil {
+		t.Fatalf("GetVirtualMachineStatus error: %v", err)
+	}
+	if statusCalls != 2 {
+		t.Fatalf("expected the shutdown to invalidate the cached status, got %d requests", statusCalls)
+	}
+}
+
+func TestResponseCache_SharedFetchOutlivesFirstCaller(t *testing.T) {
+	t.Parallel()
+
+	cache := newResponseCache(time.Minute)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (string, error) {
+		close(started)
+		<-release
+		return "value", ctx.Err()
+	}
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := cached(firstCtx, cache, "key", fetch)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan string, 1)
+	go func() {
+		v, err := cached(context.Background(), cache, "key", func(context.Context) (string, error) {
+			return "", errors.New("expected the in-flight fetch to be shared")
+		})
+		if err != nil {
+			t.Errorf("second caller: %v", err)
+		}
+		second <- v
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the first caller to stop waiting, got %v", err)
+	}
+	close(release)
+	if v := <-second; v != "value" {
+		t.Fatalf("unexpected value for the second caller: %q", v)
+	}
+}
+
+func TestResponseCache_Invalidate(t *testing.T) {
+	t.Parallel()
+
+	cache := newResponseCache(time.Minute)
+	for _, key := range []string{
+		cacheKeyVMStatus + "abc",
+		cacheKeyVMStatus + "abcdef",
+		cacheKeyBandwidthPackages + "region=ap-guangzhou",
+		cacheKeyBandwidthPackages + "region=ap-hongkong",
+		cacheKeyZones,
+	} {
+		cache.put(key, key, 0)
+	}
+
+	cache.invalidate(cacheKeyVMStatus + "abc")
+	cache.invalidate(cacheKeyBandwidthPackages)
+
+	for key, want := range map[string]bool{
+		cacheKeyVMStatus + "abc":                          false,
+		cacheKeyVMStatus + "abcdef":                       true,
+		cacheKeyBandwidthPackages + "region=ap-guangzhou": false,
+		cacheKeyBandwidthPackages + "region=ap-hongkong":  false,
+		cacheKeyZones: true,
+	} {
+		if _, ok := cache.get(key); ok != want {
+			t.Errorf("entry %s cached = %t, want %t", key, ok, want)
+		}
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	t.Parallel()
+
+	got := redactBody([]byte(`{"name":"vm","rootLoginPassword":"hunter2","cloudInitData":"I2Nsb3VkLWNvbmZpZw==","nested":[{"password":"p"}],"token":"t"}`))
+	for _, secret := range []string{"hunter2", "I2Nsb3VkLWNvbmZpZw==", `"p"`, `"t"`} {
+		if strings.Contains(got, secret) {
+			t.Fatalf("secret %s not redacted: %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, `"name":"vm"`) {
+		t.Fatalf("expected non-sensitive fields to be kept: %s", got)
+	}
+
+	if got := redactBody([]byte("plain text")); strings.Contains(got, "plain") {
+		t.Fatalf("expected non-JSON body to be redacted: %s", got)
+	}
+}
+
+func TestAuthSchemes(t *testing.T) {
+	t.Parallel()
+
+	for header, want := range map[string]string{
+		"":                        "none",
+		"Bearer legacy":           "Bearer",
+		"Bearer legacy, Bearer j": "Bearer, Bearer",
+	} {
+		if got := authSchemes(header); got != want {
+			t.Errorf("authSchemes(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestClient_TraceFile(t *testing.T) {
+	t.Parallel()
+
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(`{"status":400,"message":"invalid zone"}`)),
+		}, nil
+	})
+
+	// Logs of earlier provider processes are replaced rather than extended.
+	traceFile := filepath.Join(t.TempDir(), "penguin.har")
+	if err := os.WriteFile(traceFile, []byte(`{"log":{"version":"1.2","entries":[{"comment":"stale"}]}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient("http://example.com", "secret-token", "", ClientOptions{
+		HTTPClient: &http.Client{Transport: transport},
+		TraceFile:  traceFile,
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	for range 2 {
+		_, err = client.CreateVirtualMachine(context.Background(), CreateVirtualMachineRequest{Name: "vm", RootLoginPassword: ptr("hunter2")})
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+	}
+
+	data, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Fatalf("read trace file: %v", err)
+	}
+	if strings.Contains(string(data), "secret-token") || strings.Contains(string(data), "hunter2") {
+		t.Fatalf("trace file contains secrets: %s", data)
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("decode trace file: %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 2 {
+		t.Fatalf("unexpected HAR log: %#v", har.Log)
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.Method != http.MethodPost || entry.Response.Status != http.StatusBadRequest || !strings.Contains(entry.Response.Content.Text, "invalid zone") {
+		t.Fatalf("unexpected HAR entry: %#v", entry)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestClient_Tracing(t *testing.T) {
+	t.Parallel()
+
+	var traceparent string
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		traceparent = r.Header.Get("traceparent")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(`{"id":"vm-1","instanceState":"RUNNING"}`)),
+		}, nil
+	})
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	client, err := NewClient("http://example.com", "", "", ClientOptions{
+		HTTPClient:     &http.Client{Transport: transport},
+		TracerProvider: provider,
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	if _, err := client.GetVirtualMachineStatus(context.Background(), "vm-1"); err != nil {
+		t.Fatalf("GetVirtualMachineStatus error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /tencentcloud/vms/:id/status" {
+		t.Fatalf("unexpected span name: %s", span.Name)
+	}
+	attrs := map[string]string{}
+	for _, kv := range span.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["http.response.status_code"] != "200" || attrs["penguin.attempts"] != "1" || attrs["url.path"] != "/tencentcloud/vms/vm-1/status" {
+		t.Fatalf("unexpected span attributes: %v", attrs)
+	}
+	if !strings.Contains(traceparent, span.SpanContext.TraceID().String()) {
+		t.Fatalf("expected traceparent for trace %s, got %q", span.SpanContext.TraceID(), traceparent)
+	}
+}
+
+func TestClient_MutualTLS(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "penguin test client CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "terraform" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, `{"zones":[{"region":"ap-guangzhou","zone":"ap-guangzhou-3","state":"AVAILABLE"}]}`)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCAPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	t.Run("client certificate", func(t *testing.T) {
+		client, err := NewClient(server.URL, "", "", ClientOptions{TLS: &TLSOptions{
+			CACertPEM:     serverCAPEM,
+			ClientCertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientDER}),
+			ClientKeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: clientKeyDER}),
+			ServerName:    "example.com",
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		zones, err := client.ListZones(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(zones) != 1 || zones[0].Zone != "ap-guangzhou-3" {
+			t.Fatalf("unexpected zones: %#v", zones)
+		}
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		client, err := NewClient(server.URL, "", "", ClientOptions{TLS: &TLSOptions{CACertPEM: serverCAPEM, ServerName: "example.com"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.ListZones(context.Background()); err == nil {
+			t.Fatal("expected handshake failure without client certificate")
+		}
+	})
+
+	t.Run("untrusted server", func(t *testing.T) {
+		client, err := NewClient(server.URL, "", "", ClientOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.ListZones(context.Background()); err == nil {
+			t.Fatal("expected certificate verification failure")
+		}
+	})
+
+	t.Run("incomplete key pair", func(t *testing.T) {
+		_, err := NewClient(server.URL, "", "", ClientOptions{TLS: &TLSOptions{ClientCertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientDER})}})
+		if err == nil || !strings.Contains(err.Error(), "configure TLS") {
+			t.Fatalf("expected TLS configuration error, got %v", err)
+		}
+	})
+}
+
+func TestClient_UnixSocketEndpoint(t *testing.T) {
+	// Socket paths are limited to ~104 bytes, which t.TempDir under a long $TMPDIR (macOS) can
+	// exceed, so use a short directory directly below the temp root.
+	dir, err := os.MkdirTemp("", "penguin") //nolint:usetesting // see above
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "penguin.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"zones":[{"region":"ap-guangzhou","zone":%q,"state":"AVAILABLE"}]}`, r.URL.Path)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	for endpoint, wantPath := range map[string]string{
+		"unix://" + socket: "/tencentcloud/zones",
+		"http+unix://" + url.PathEscape(socket) + "/api/": "/api/tencentcloud/zones",
+	} {
+		client, err := NewClient(endpoint, "", "", ClientOptions{})
+		if err != nil {
+			t.Fatalf("NewClient(%q) error: %v", endpoint, err)
+		}
+		zones, err := client.ListZones(context.Background())
+		if err != nil {
+			t.Fatalf("ListZones via %q error: %v", endpoint, err)
+		}
+		if len(zones) != 1 || zones[0].Zone != wantPath {
+			t.Fatalf("unexpected zones via %q: %#v", endpoint, zones)
+		}
+	}
+
+	for _, endpoint := range []string{"unix://", "unix://host/run/penguin.sock"} {
+		if _, err := NewClient(endpoint, "", "", ClientOptions{}); err == nil {
+			t.Fatalf("expected error for %q", endpoint)
+		}
+	}
+}
+
+type credentialSourceFunc func(ctx context.Context, refresh bool) (Credentials, error)
+
+func (f credentialSourceFunc) Credentials(ctx context.Context, refresh bool) (Credentials, error) {
+	return f(ctx, refresh)
+}
+
+func TestClient_RefreshesCredentialsOnUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	refreshes := 0
+	source := credentialSourceFunc(func(ctx context.Context, refresh bool) (Credentials, error) {
+		if refresh {
+			refreshes++
+		}
+		if refreshes > 0 {
+			return Credentials{JWT: "fresh"}, nil
+		}
+		return Credentials{JWT: "expired"}, nil
+	})
+
+	var seen []string
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			return &http.Response{
+				StatusCode: http.StatusUnauthorized,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":401,"message":"token expired"}`)),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"id":"vm-123"}`)),
+		}, nil
+	})
+
+	client, err := NewClient("http://example.com", "ignored", "", ClientOptions{
+		HTTPClient:  &http.Client{Transport: transport},
+		Credentials: source,
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	// Creating a VM is never retried for transient failures, but a 401 is safe to repeat.
+	out, err := client.CreateVirtualMachine(context.Background(), CreateVirtualMachineRequest{Name: "vm"})
+	if err != nil {
+		t.Fatalf("CreateVirtualMachine error: %v", err)
+	}
+	if out.ID != "vm-123" || refreshes != 1 || strings.Join(seen, ";") != "Bearer expired;Bearer fresh" {
+		t.Fatalf("unexpected result %#v after %d refreshes, headers %q", out, refreshes, seen)
+	}
+
+	// A second 401 after refreshing is reported.
+	refreshes = -10
+	seen = nil
+	if _, err := client.CreateVirtualMachine(context.Background(), CreateVirtualMachineRequest{Name: "vm"}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected one refresh attempt, got headers %q", seen)
+	}
+}
+
+func TestJWTIssuer(t *testing.T) {
+	t.Parallel()
+
+	issued := 0
+	var expiresIn time.Duration
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/auth/jwt":
+			if got := r.Header.Get("Authorization"); got != "Bearer legacy" {
+				t.Fatalf("unexpected auth header for issuing: %q", got)
+			}
+			issued++
+			payload, _ := json.Marshal(IssueJWTResponse{
+				Token:     fmt.Sprintf("jwt-%d", issued),
+				ExpiresAt: time.Now().Add(expiresIn).UTC().Format(time.RFC3339),
+			})
+			return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(bytes.NewReader(payload))}, nil
+		case "/_internal/health":
+			if got, want := r.Header.Get("Authorization"), fmt.Sprintf("Bearer legacy, Bearer jwt-%d", issued); got != want {
+				t.Fatalf("unexpected auth header %q, want %q", got, want)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"status":"ok"}`))}, nil
+		}
+		t.Fatalf("unexpected path: %s", r.URL.Path)
+		return nil, nil
+	})
+
+	opts := ClientOptions{HTTPClient: &http.Client{Transport: transport}}
+	issuingClient, err := NewClient("http://example.com", "legacy", "", opts)
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	opts.Credentials = NewJWTIssuer(issuingClient, "legacy", IssueJWTRequest{TTLMinutes: 60, AllowedZones: []string{"ap-guangzhou-3"}})
+	client, err := NewClient("http://example.com", "", "", opts)
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	// An already expired JWT is re-issued on every request.
+	expiresIn = -time.Minute
+	for range 2 {
+		if _, err := client.InternalHealth(context.Background()); err != nil {
+			t.Fatalf("InternalHealth error: %v", err)
+		}
+	}
+	if issued != 2 {
+		t.Fatalf("expected the expired JWT to be re-issued, issued %d", issued)
+	}
+
+	expiresIn = time.Hour
+	for range 2 {
+		if _, err := client.InternalHealth(context.Background()); err != nil {
+			t.Fatalf("InternalHealth error: %v", err)
+		}
+	}
+	if issued != 3 {
+		t.Fatalf("expected the valid JWT to be reused, issued %d", issued)
+	}
+}
+
+func TestClient_FailoverEndpoints(t *testing.T) {
+	t.Parallel()
+
+	newClient := func(t *testing.T, handle func(r *http.Request) (int, error)) (*Client, *[]string) {
+		t.Helper()
+		var mu sync.Mutex
+		var calls []string
+		transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			mu.Lock()
+			calls = append(calls, r.Method+" "+r.URL.Host+r.URL.Path)
+			mu.Unlock()
+			status, err := handle(r)
+			if err != nil {
+				return nil, err
+			}
+			body := `{}`
+			if r.URL.Path == "/tencentcloud/zones" {
+				body = fmt.Sprintf(`{"zones":[{"zone":%q}]}`, r.URL.Host)
+			}
+			return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+		})
+		client, err := NewClient("http://a.example", "", "", ClientOptions{
+			HTTPClient:        &http.Client{Transport: transport},
+			FailoverEndpoints: []string{"http://b.example"},
+		})
+		if err != nil {
+			t.Fatalf("NewClient error: %v", err)
+		}
+		return client, &calls
+	}
+	refused := errors.New("connection refused")
+
+	t.Run("unhealthy primary is skipped", func(t *testing.T) {
+		client, calls := newClient(t, func(r *http.Request) (int, error) {
+			if r.URL.Host == "a.example" {
+				return 0, refused
+			}
+			return http.StatusOK, nil
+		})
+		for range 2 {
+			zones, err := client.ListZones(WithoutCache(context.Background()))
+			if err != nil {
+				t.Fatalf("ListZones error: %v", err)
+			}
+			if zones[0].Zone != "b.example" {
+				t.Fatalf("expected the secondary to answer, got %#v", zones)
+			}
+		}
+		want := "GET a.example/health;GET b.example/health;GET b.example/tencentcloud/zones;GET b.example/tencentcloud/zones"
+		if got := strings.Join(*calls, ";"); got != want {
+			t.Fatalf("unexpected calls:\n got %s\nwant %s", got, want)
+		}
+	})
+
+	t.Run("fails over on 503", func(t *testing.T) {
+		client, calls := newClient(t, func(r *http.Request) (int, error) {
+			if r.URL.Host == "a.example" && r.URL.Path != "/health" {
+				return http.StatusServiceUnavailable, nil
+			}
+			return http.StatusOK, nil
+		})
+		zones, err := client.ListZones(context.Background())
+		if err != nil {
+			t.Fatalf("ListZones error: %v", err)
+		}
+		if zones[0].Zone != "b.example" {
+			t.Fatalf("expected the secondary to answer, got %#v", zones)
+		}
+		want := "GET a.example/health;GET a.example/tencentcloud/zones;GET b.example/health;GET b.example/tencentcloud/zones"
+		if got := strings.Join(*calls, ";"); got != want {
+			t.Fatalf("unexpected calls:\n got %s\nwant %s", got, want)
+		}
+	})
+
+	t.Run("never replays unsafe posts", func(t *testing.T) {
+		client, calls := newClient(t, func(r *http.Request) (int, error) {
+			if r.Method != http.MethodPost {
+				return http.StatusOK, nil
+			}
+			if r.URL.Host == "a.example" {
+				return 0, refused
+			}
+			return http.StatusAccepted, nil
+		})
+		if _, err := client.CreateVirtualMachine(context.Background(), CreateVirtualMachineRequest{Name: "vm"}); err == nil {
+			t.Fatal("expected the create to fail")
+		}
+		for _, call := range *calls {
+			if strings.HasPrefix(call, "POST b.example") {
+				t.Fatalf("create was replayed on the secondary: %q", *calls)
+			}
+		}
+
+		// Retry-safe requests move on to the secondary when the primary fails again.
+		if err := client.StartVirtualMachine(context.Background(), "vm-1"); err != nil {
+			t.Fatalf("StartVirtualMachine error: %v", err)
+		}
+		if last := (*calls)[len(*calls)-1]; last != "POST b.example/tencentcloud/vms/vm-1/start" {
+			t.Fatalf("expected start on the secondary, got %q", last)
+		}
+	})
+
+	t.Run("lone transient 503 keeps the primary", func(t *testing.T) {
+		var failed atomic.Bool
+		client, calls := newClient(t, func(r *http.Request) (int, error) {
+			if r.URL.Host == "a.example" && r.URL.Path != "/health" && failed.CompareAndSwap(false, true) {
+				return http.StatusServiceUnavailable, nil
+			}
+			return http.StatusOK, nil
+		})
+		for _, want := range []string{"b.example", "a.example"} {
+			zones, err := client.ListZones(WithoutCache(context.Background()))
+			if err != nil {
+				t.Fatalf("ListZones error: %v", err)
+			}
+			if zones[0].Zone != want {
+				t.Fatalf("expected %s to answer, got %#v (calls %q)", want, zones, *calls)
+			}
+		}
+	})
+
+	t.Run("ejects after repeated failures", func(t *testing.T) {
+		client, calls := newClient(t, func(r *http.Request) (int, error) {
+			if r.URL.Host == "a.example" && r.URL.Path != "/health" {
+				return http.StatusServiceUnavailable, nil
+			}
+			return http.StatusOK, nil
+		})
+		for range endpointFailureThreshold + 1 {
+			if _, err := client.ListZones(WithoutCache(context.Background())); err != nil {
+				t.Fatalf("ListZones error: %v", err)
+			}
+		}
+		primary := 0
+		for _, call := range *calls {
+			if call == "GET a.example/tencentcloud/zones" {
+				primary++
+			}
+		}
+		if primary != endpointFailureThreshold {
+			t.Fatalf("expected the primary to be ejected after %d failures, got calls %q", endpointFailureThreshold, *calls)
+		}
+	})
+}
+
+func TestClient_RegionEndpoints(t *testing.T) {
+	t.Parallel()
+
+	if got := RegionFromZone("ap-guangzhou-3"); got != "ap-guangzhou" {
+		t.Fatalf("unexpected region %q", got)
+	}
+
+	vmHosts := map[string]string{"vm-sh": "ap-shanghai.example", "vm-gz": "ap-guangzhou.example"}
+	var mu sync.Mutex
+	var calls []string
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Host+r.URL.Path+" "+r.Header.Get("Authorization"))
+		mu.Unlock()
+
+		status, body := http.StatusOK, `{}`
+		switch {
+		case r.URL.Path == "/tencentcloud/zones":
+			region := strings.TrimSuffix(r.URL.Host, ".example")
+			body = fmt.Sprintf(`{"zones":[{"region":%q,"zone":"%s-1"},{"region":"shared","zone":"shared-1"}]}`, region, region)
+		case r.URL.Path == "/tencentcloud/vms":
+			status, body = http.StatusCreated, `{"id":"vm-sh"}`
+		case strings.HasPrefix(r.URL.Path, "/tencentcloud/vms/"):
+			id, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/tencentcloud/vms/"), "/")
+			if id == "vm-down" && r.URL.Host == "ap-guangzhou.example" {
+				status, body = http.StatusInternalServerError, `{"status":500,"message":"database unavailable"}`
+			} else if vmHosts[id] != r.URL.Host {
+				status, body = http.StatusNotFound, `{"status":404,"message":"not found"}`
+			} else {
+				body = fmt.Sprintf(`{"id":%q,"zone":%q}`, id, r.URL.Host)
+			}
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+	})
+
+	client, err := NewClient("", "shared-token", "", ClientOptions{
+		HTTPClient: &http.Client{Transport: transport},
+		Regions: map[string]RegionEndpoint{
+			"ap-guangzhou": {Endpoint: "http://ap-guangzhou.example"},
+			"ap-shanghai":  {Endpoint: "http://ap-shanghai.example", LegacyToken: "sh-token"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	ctx := context.Background()
+
+	zones, err := client.ListZones(ctx)
+	if err != nil {
+		t.Fatalf("ListZones error: %v", err)
+	}
+	var names []string
+	for _, z := range zones {
+		names = append(names, z.Zone)
+	}
+	if got := strings.Join(names, ","); got != "ap-guangzhou-1,shared-1,ap-shanghai-1" {
+		t.Fatalf("unexpected aggregated zones: %s", got)
+	}
+
+	calls = nil
+	out, err := client.CreateVirtualMachine(ctx, CreateVirtualMachineRequest{Zone: "ap-shanghai-2"})
+	if err != nil {
+		t.Fatalf("CreateVirtualMachine error: %v", err)
+	}
+	if _, err := client.GetVirtualMachineStatus(ctx, out.ID); err != nil {
+		t.Fatalf("GetVirtualMachineStatus error: %v", err)
+	}
+	// The VM was created in Shanghai, so its status is read there without a lookup.
+	want := "POST ap-shanghai.example/tencentcloud/vms Bearer sh-token;GET ap-shanghai.example/tencentcloud/vms/vm-sh/status Bearer sh-token"
+	if got := strings.Join(calls, ";"); got != want {
+		t.Fatalf("unexpected calls:\n got %s\nwant %s", got, want)
+	}
+
+	// An unknown VM is looked up in each deployment and then remembered.
+	calls = nil
+	for range 2 {
+		status, err := client.GetVirtualMachineStatus(WithoutCache(ctx), "vm-gz")
+		if err != nil {
+			t.Fatalf("GetVirtualMachineStatus error: %v", err)
+		}
+		if status.Zone != "ap-guangzhou.example" {
+			t.Fatalf("unexpected status: %#v", status)
+		}
+	}
+	if len(calls) != 3 || !strings.HasSuffix(calls[0], "Bearer shared-token") {
+		t.Fatalf("expected one lookup and two reads, got %q", calls)
+	}
+
+	// Without a default endpoint, a VM that no deployment knows is gone.
+	if err := client.StartVirtualMachine(ctx, "vm-gone"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a VM missing everywhere, got %v", err)
+	}
+	// A deployment that fails the lookup may hold the VM, so its error is reported as is.
+	if err := client.StartVirtualMachine(ctx, "vm-down"); err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), `region "ap-guangzhou"`) {
+		t.Fatalf("expected the lookup error of ap-guangzhou, got %v", err)
+	}
+
+	for region, regional := range client.regions.clients {
+		if regional.limiter != client.limiter {
+			t.Fatalf("expected region %s to share the request limiter", region)
+		}
+	}
+
+	if err := client.Health(ctx); err == nil || !strings.Contains(err.Error(), "no default endpoint") {
+		t.Fatalf("expected missing default endpoint error, got %v", err)
+	}
+	if err := client.DeleteElasticIP(ctx, "eu-frankfurt", "eip-1"); err == nil || !strings.Contains(err.Error(), `region "eu-frankfurt"`) {
+		t.Fatalf("expected unconfigured region error, got %v", err)
+	}
+}
+
+func TestClient_GetImageByName(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]string{
+		"Ubuntu Server 22.04": "/tencentcloud/images/Ubuntu%20Server%2022.04",
+		"team/ubuntu":         "/tencentcloud/images/team%2Fubuntu",
+		"..":                  "/tencentcloud/images/%2E%2E",
+	} {
+		transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method != http.MethodGet || r.URL.EscapedPath() != want {
+				t.Fatalf("unexpected request for %q: %s %s", name, r.Method, r.URL.EscapedPath())
+			}
+			if got := r.URL.Query().Get("region"); got != "ap-guangzhou" {
+				t.Fatalf("unexpected region: %q", got)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(bytes.NewBufferString(`{"imageId":"img-487zeit5","imageName":"Ubuntu Server 22.04","imageType":"PUBLIC_IMAGE","osName":"Ubuntu Server 22.04 LTS 64bit","imageSize":20}`)),
+			}, nil
+		})
+
+		client, err := NewClient("http://example.com", "", "", ClientOptions{
+			HTTPClient: &http.Client{Transport: transport},
+		})
+		if err != nil {
+			t.Fatalf("NewClient error: %v", err)
+		}
+
+		out, err := client.GetImageByName(context.Background(), "ap-guangzhou", name)
+		if err != nil {
+			t.Fatalf("GetImageByName error: %v", err)
+		}
+		if out.ID != "img-487zeit5" || out.Type != "PUBLIC_IMAGE" || out.SizeGiB != 20 {
+			t.Fatalf("unexpected response: %#v", out)
+		}
+	}
+}
+
+func TestClient_ListMissingVirtualMachines(t *testing.T) {
+	t.Parallel()
+
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method != http.MethodGet || r.URL.Path != "/tencentcloud/vms/missing" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"vm-1","zone":"ap-guangzhou-3","instanceId":"ins-1"}]`)),
+		}, nil
+	})
+
+	client, err := NewClient("http://example.com", "", "", ClientOptions{
+		HTTPClient: &http.Client{Transport: transport},
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	out, err := client.ListMissingVirtualMachines(context.Background())
+	if err != nil {
+		t.Fatalf("ListMissingVirtualMachines error: %v", err)
+	}
+	if len(out) != 1 || out[0] != (MissingVirtualMachine{ID: "vm-1", Zone: "ap-guangzhou-3", InstanceID: "ins-1"}) {
+		t.Fatalf("unexpected response: %#v", out)
+	}
+}
+
+func TestClient_InternalMetrics(t *testing.T) {
+	t.Parallel()
+
+	const exposition = `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",path="/vms/{id}",note="a \"quoted\" \\ value"} 42
+process_uptime_seconds 12.5 1700000000000
+`
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method != http.MethodGet || r.URL.Path != "/_internal/metrics" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/plain; version=0.0.4"}},
+			Body:       io.NopCloser(bytes.NewBufferString(exposition)),
+		}, nil
+	})
+
+	client, err := NewClient("http://example.com", "", "", ClientOptions{
+		HTTPClient: &http.Client{Transport: transport},
+	})
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+
+	out, err := client.InternalMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("InternalMetrics error: %v", err)
+	}
+	if out.Raw != exposition {
+		t.Fatalf("unexpected raw metrics: %q", out.Raw)
+	}
+	want := []MetricSample{
+		{Name: "http_requests_total", Labels: map[string]string{"method": "GET", "path": "/vms/{id}", "note": `a "quoted" \ value`}, Value: 42},
+		{Name: "process_uptime_seconds", Labels: map[string]string{}, Value: 12.5},
+	}
+	if !reflect.DeepEqual(out.Samples, want) {
+		t.Fatalf("unexpected samples: %#v", out.Samples)
+	}
+}
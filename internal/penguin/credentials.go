@@ -0,0 +1,34 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"context"
+	"fmt"
+)
+
+// Credentials are the tokens sent in the Authorization header.
+type Credentials struct {
+	LegacyToken string
+	JWT         string
+}
+
+// CredentialSource supplies credentials that may change while the client is in use, such as
+// short lived tokens kept in a vault. Implementations cache their credentials; refresh asks for
+// new ones after the API rejected the current ones with 401.
+type CredentialSource interface {
+	Credentials(ctx context.Context, refresh bool) (Credentials, error)
+}
+
+// authorization returns the Authorization header for the next request.
+func (c *Client) authorization(ctx context.Context, refresh bool) (string, error) {
+	if c.credentials == nil {
+		return c.authHeader, nil
+	}
+	creds, err := c.credentials.Credentials(ctx, refresh)
+	if err != nil {
+		return "", fmt.Errorf("get credentials: %w", err)
+	}
+	return buildAuthHeader(creds.LegacyToken, creds.JWT), nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by *APIError via errors.Is.
+var (
+	// ErrNotFound means the requested record does not exist.
+	ErrNotFound = errors.New("penguin: not found")
+	// ErrUnauthorized means the legacy token or JWT is missing, invalid or expired.
+	ErrUnauthorized = errors.New("penguin: unauthorized")
+	// ErrForbidden means the JWT does not allow the operation, e.g. a `projectId` mismatch.
+	ErrForbidden = errors.New("penguin: forbidden")
+	// ErrConflict means the service rejected the request in the current state, e.g. a start
+	// while the transfer quota is exceeded or a renewal of a postpaid instance.
+	ErrConflict = errors.New("penguin: conflict")
+	// ErrNoBandwidthPackage means no schedulable shared bandwidth package is available.
+	ErrNoBandwidthPackage = errors.New("penguin: no schedulable bandwidth package available")
+	// ErrUpstream means a Tencent Cloud API call made by the service failed.
+	ErrUpstream = errors.New("penguin: upstream Tencent Cloud API failed")
+)
+
+// Is reports whether the error belongs to the class represented by target.
+func (e *APIError) Is(target error) bool {
+	if e == nil {
+		return false
+	}
+	message := strings.ToLower(e.Message)
+
+	switch target {
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.Status == http.StatusForbidden
+	case ErrConflict:
+		return e.Status == http.StatusConflict
+	case ErrNoBandwidthPackage:
+		return e.Status == http.StatusServiceUnavailable && strings.Contains(message, "no schedulable bandwidth package available")
+	case ErrUpstream:
+		return e.Status == http.StatusBadGateway || strings.Contains(message, "failed to query bandwidth packages") || e.isTencentCloudFailure()
+	}
+	return false
+}
+
+// isTencentCloudFailure reports whether the error is the 500 documented for `/tencentcloud/`
+// endpoints as "Tencent Cloud API returned an error". Resetting transfer usage is excluded: it
+// only touches the service database, so its 500 means the database update failed.
+func (e *APIError) isTencentCloudFailure() bool {
+	return e.Status == http.StatusInternalServerError &&
+		strings.HasPrefix(e.Path, "/tencentcloud/") &&
+		!strings.HasSuffix(e.Path, "/reset-transfer")
+}
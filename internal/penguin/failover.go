@@ -0,0 +1,252 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// endpointFailureThreshold is how many requests in a row must fail with a connection error,
+	// 502 or 503 before an endpoint is ejected, so a lone transient error only fails over the
+	// request that hit it.
+	endpointFailureThreshold = 3
+
+	// endpointEjectDuration is how long an endpoint is skipped after it is ejected or fails a
+	// health check. It is then half-open: it is health checked again before receiving traffic,
+	// and the next failure ejects it again until a request succeeds.
+	endpointEjectDuration = 30 * time.Second
+)
+
+type endpointState int
+
+const (
+	endpointUnchecked endpointState = iota
+	endpointHealthy
+	endpointEjected
+)
+
+// serviceEndpoint is one Penguin replica. With several endpoints the client routes every request
+// to the first healthy one; a single endpoint is always used as is.
+type serviceEndpoint struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+
+	// probeMu serializes health checks so that concurrent requests wait for one probe.
+	probeMu sync.Mutex
+
+	mu           sync.Mutex
+	state        endpointState
+	ejectedUntil time.Time
+	failures     int
+}
+
+// newEndpoint parses raw and picks its HTTP client: opts.HTTPClient if set, a Unix socket dialer
+// for unix endpoints, otherwise shared.
+func newEndpoint(raw string, opts ClientOptions, shared func() (*http.Client, error)) (*serviceEndpoint, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, errors.New("endpoint is required")
+	}
+
+	socket, parsed, isUnix, err := parseUnixEndpoint(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !isUnix {
+		parsed, err = url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parse endpoint: %w", err)
+		}
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("endpoint must include scheme and host, got %q", raw)
+	}
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		if isUnix {
+			if opts.TLS != nil {
+				return nil, errors.New("TLS options cannot be used with a unix socket endpoint")
+			}
+			httpClient = newUnixSocketHTTPClient(socket)
+		} else if httpClient, err = shared(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &serviceEndpoint{baseURL: parsed, httpClient: httpClient}, nil
+}
+
+// urlFor returns the URL of p below the base URL. p must already be escaped, e.g. with
+// pathSegment, and is used as is: it is not cleaned, so escaped segments reach the service intact.
+func (e *serviceEndpoint) urlFor(p string) string {
+	unescaped, err := url.PathUnescape(p)
+	if err != nil {
+		// Every caller escapes its segments, so this only guards against a malformed literal.
+		unescaped = p
+	}
+	clone := *e.baseURL
+	clone.Path = e.baseURL.Path + unescaped
+	clone.RawPath = e.baseURL.EscapedPath() + p
+	return clone.String()
+}
+
+// pathSegment escapes s for use as a single path segment. The dot segments "." and ".." are
+// escaped too, since servers and proxies would otherwise resolve them.
+func pathSegment(s string) string {
+	if s == "." || s == ".." {
+		return strings.ReplaceAll(s, ".", "%2E")
+	}
+	return url.PathEscape(s)
+}
+
+func (e *serviceEndpoint) String() string {
+	return e.baseURL.String()
+}
+
+// status reports the endpoint state; an ejection that has run out needs a new health check.
+func (e *serviceEndpoint) status(now time.Time) endpointState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state == endpointEjected && !now.Before(e.ejectedUntil) {
+		e.state = endpointUnchecked
+	}
+	return e.state
+}
+
+// markHealthy lets e receive traffic after a successful health check. A half-open endpoint
+// stays one failure away from ejection until a request succeeds.
+func (e *serviceEndpoint) markHealthy() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.state = endpointHealthy
+}
+
+func (e *serviceEndpoint) eject(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ejectLocked(now)
+}
+
+func (e *serviceEndpoint) ejectLocked(now time.Time) {
+	e.state, e.ejectedUntil = endpointEjected, now.Add(endpointEjectDuration)
+	e.failures = endpointFailureThreshold - 1
+}
+
+// recordFailure counts a failed request and reports whether it ejected e.
+func (e *serviceEndpoint) recordFailure(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures++
+	if e.failures < endpointFailureThreshold {
+		return false
+	}
+	e.ejectLocked(now)
+	return true
+}
+
+func (e *serviceEndpoint) recordSuccess() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.state, e.failures = endpointHealthy, 0
+}
+
+// isFailoverStatus reports responses that indicate the replica itself is unavailable.
+func isFailoverStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable
+}
+
+type pinnedEndpointKey struct{}
+
+// withPinnedEndpoint sends requests made with ctx to e only, without retries or failover.
+func withPinnedEndpoint(ctx context.Context, e *serviceEndpoint) context.Context {
+	return context.WithValue(ctx, pinnedEndpointKey{}, e)
+}
+
+func pinnedEndpoint(ctx context.Context) *serviceEndpoint {
+	e, _ := ctx.Value(pinnedEndpointKey{}).(*serviceEndpoint)
+	return e
+}
+
+// selectEndpoint returns the first healthy endpoint not in tried, health checking endpoints that
+// were never used or whose ejection ran out. It returns nil once every endpoint has been tried.
+// If all endpoints are ejected, the first is used anyway so the request reports a real error.
+func (c *Client) selectEndpoint(ctx context.Context, tried map[*serviceEndpoint]bool) *serviceEndpoint {
+	if pinned := pinnedEndpoint(ctx); pinned != nil {
+		if tried[pinned] {
+			return nil
+		}
+		return pinned
+	}
+	if len(c.endpoints) == 1 {
+		if tried[c.endpoints[0]] {
+			return nil
+		}
+		return c.endpoints[0]
+	}
+
+	for _, e := range c.endpoints {
+		if !tried[e] && c.checkEndpoint(ctx, e) {
+			return e
+		}
+	}
+	if len(tried) > 0 {
+		return nil
+	}
+	return c.endpoints[0]
+}
+
+// checkEndpoint reports whether e may receive traffic, probing it with Health when its state is
+// unknown.
+func (c *Client) checkEndpoint(ctx context.Context, e *serviceEndpoint) bool {
+	switch e.status(time.Now()) {
+	case endpointHealthy:
+		return true
+	case endpointEjected:
+		return false
+	}
+
+	e.probeMu.Lock()
+	defer e.probeMu.Unlock()
+	// Another request may have finished a probe while this one waited.
+	if state := e.status(time.Now()); state != endpointUnchecked {
+		return state == endpointHealthy
+	}
+
+	if err := c.Health(withPinnedEndpoint(ctx, e)); err != nil {
+		if ctx.Err() == nil {
+			tflog.Warn(ctx, "Penguin endpoint failed health check", map[string]any{"endpoint": e.String(), "error": err.Error()})
+			e.eject(time.Now())
+		}
+		return false
+	}
+	e.markHealthy()
+	return true
+}
+
+// recordEndpointResult counts a transport error, 502 or 503 against e, ejecting it once
+// endpointFailureThreshold requests failed in a row, and resets the count after any other
+// response. Errors caused by the caller's context do not count against the endpoint.
+func (c *Client) recordEndpointResult(ctx context.Context, e *serviceEndpoint, status int, err error) {
+	if len(c.endpoints) == 1 || ctx.Err() != nil {
+		return
+	}
+	if err != nil || isFailoverStatus(status) {
+		if e.recordFailure(time.Now()) {
+			tflog.Warn(ctx, "Ejecting unavailable Penguin endpoint", map[string]any{"endpoint": e.String(), "status": status, "eject_for": endpointEjectDuration.String()})
+		}
+		return
+	}
+	e.recordSuccess()
+}
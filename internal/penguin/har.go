@@ -0,0 +1,232 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// harRecorders shares one recorder per file within the process, so provider aliases configured
+// with the same trace file append to it instead of overwriting each other.
+var (
+	harRecordersMu sync.Mutex
+	harRecorders   = map[string]*harRecorder{}
+)
+
+// harLogClose terminates the entries array and the document after the last entry.
+const harLogClose = "\n]}}\n"
+
+// harRecorder writes request/response pairs as a HAR 1.2 file. Each entry is written over the
+// closing brackets of the previous write, followed by new ones, so the file stays a complete
+// document whenever the provider process exits without keeping entries in memory.
+type harRecorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	end     int64
+	entries int
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// openHARRecorder returns the recorder for path. The file is truncated when the process first
+// opens it, so it only holds the requests of the current provider process.
+func openHARRecorder(path string, creator string) (*harRecorder, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolve HTTP trace file: %w", err)
+	}
+
+	harRecordersMu.Lock()
+	defer harRecordersMu.Unlock()
+
+	if r, ok := harRecorders[abs]; ok {
+		return r, nil
+	}
+
+	header, err := json.Marshal(harLog{Version: "1.2", Creator: harCreator{Name: creator}, Entries: []harEntry{}})
+	if err != nil {
+		return nil, fmt.Errorf("encode HTTP trace: %w", err)
+	}
+	// Reopen the entries array of the encoded log: `...,"entries":[]}` becomes `...,"entries":[`.
+	prefix := append([]byte(`{"log":`), header[:len(header)-len("]}")]...)
+
+	file, err := os.OpenFile(abs, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open HTTP trace file: %w", err)
+	}
+	if _, err := file.Write(append(prefix, harLogClose...)); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("write HTTP trace file: %w", err)
+	}
+
+	r := &harRecorder{file: file, end: int64(len(prefix))}
+	harRecorders[abs] = r
+	return r, nil
+}
+
+// record appends one exchange. resp is nil when the request failed before a response arrived.
+func (r *harRecorder) record(req *http.Request, payload []byte, resp *http.Response, respBody []byte, started time.Time, latency time.Duration, reqErr error) {
+	entry := harEntry{
+		StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+		Time:            float64(latency.Microseconds()) / 1000,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(payload),
+		},
+		Timings: harTimings{Wait: float64(latency.Microseconds()) / 1000},
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if len(payload) > 0 {
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: redactBody(payload)}
+	}
+
+	if resp != nil {
+		entry.Response = harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(resp.Header),
+			Content:     harContent{Size: len(respBody), MimeType: resp.Header.Get("Content-Type")},
+			HeadersSize: -1,
+			BodySize:    len(respBody),
+		}
+		if len(respBody) > 0 {
+			entry.Response.Content.Text = redactBody(respBody)
+		}
+	} else {
+		entry.Response = harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+	}
+	if reqErr != nil {
+		entry.Comment = reqErr.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Tracing is a debugging aid; a failed write must not fail the request.
+	_ = r.appendLocked(entry)
+}
+
+func (r *harRecorder) appendLocked(entry harEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode HTTP trace: %w", err)
+	}
+
+	separator := "\n"
+	if r.entries > 0 {
+		separator = ",\n"
+	}
+	chunk := append([]byte(separator), data...)
+	if _, err := r.file.WriteAt(append(chunk, harLogClose...), r.end); err != nil {
+		return fmt.Errorf("write HTTP trace file: %w", err)
+	}
+	r.end += int64(len(chunk))
+	r.entries++
+	return nil
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	out := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			if http.CanonicalHeaderKey(name) == "Authorization" {
+				value = redactedValue
+			}
+			out = append(out, harNameValue{Name: name, Value: value})
+		}
+	}
+	return out
+}
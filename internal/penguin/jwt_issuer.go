@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxJWTRefreshSkew bounds how long before expiresAt a JWT is re-issued.
+const maxJWTRefreshSkew = time.Minute
+
+// JWTIssuer is a CredentialSource that sends a legacy token together with a scoped JWT it issues
+// itself via IssueJWT. The JWT is re-issued shortly before it expires, or when refresh is set.
+type JWTIssuer struct {
+	// client issues the JWTs and must authenticate with the legacy token alone.
+	client      *Client
+	legacyToken string
+	request     IssueJWTRequest
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+var _ CredentialSource = &JWTIssuer{}
+
+func NewJWTIssuer(client *Client, legacyToken string, request IssueJWTRequest) *JWTIssuer {
+	return &JWTIssuer{client: client, legacyToken: legacyToken, request: request}
+}
+
+func (i *JWTIssuer) Credentials(ctx context.Context, refresh bool) (Credentials, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.token == "" || refresh || !time.Now().Before(i.refreshAt) {
+		issuedAt := time.Now()
+		out, err := i.client.IssueJWT(ctx, i.request)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("issue JWT: %w", err)
+		}
+
+		// Fall back to the requested TTL if the service reports an unparsable expiry.
+		expiresAt, err := time.Parse(time.RFC3339, out.ExpiresAt)
+		if err != nil {
+			expiresAt = issuedAt.Add(time.Duration(i.request.TTLMinutes) * time.Minute)
+		}
+		skew := max(0, min(maxJWTRefreshSkew, expiresAt.Sub(issuedAt)/4))
+		i.token, i.refreshAt = out.Token, expiresAt.Add(-skew)
+	}
+
+	return Credentials{LegacyToken: i.legacyToken, JWT: i.token}, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"context"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// Defaults applied by the provider when requests_per_second / max_concurrent_requests are unset.
+const (
+	DefaultRequestsPerSecond     = 10
+	DefaultMaxConcurrentRequests = 8
+)
+
+// requestLimiter throttles requests shared by every resource using the client: a token bucket
+// bounds the request rate and a semaphore bounds requests in flight. Low-priority requests
+// (status polls) may only occupy all but one slot, so creates and updates are not starved by
+// many concurrent waiters.
+type requestLimiter struct {
+	rate       *rate.Limiter
+	slots      chan struct{}
+	background chan struct{}
+}
+
+func newRequestLimiter(requestsPerSecond float64, maxConcurrent int) *requestLimiter {
+	l := &requestLimiter{}
+	if requestsPerSecond > 0 {
+		l.rate = rate.NewLimiter(rate.Limit(requestsPerSecond), int(math.Ceil(requestsPerSecond)))
+	}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+		if maxConcurrent > 1 {
+			l.background = make(chan struct{}, maxConcurrent-1)
+		}
+	}
+	return l
+}
+
+// acquire blocks until the request may be sent. The returned func releases its slot.
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	var held []chan struct{}
+	release := func() {
+		for _, ch := range held {
+			<-ch
+		}
+	}
+
+	if l.background != nil && isLowPriority(ctx) {
+		select {
+		case l.background <- struct{}{}:
+			held = append(held, l.background)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			held = append(held, l.slots)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+type lowPriorityKey struct{}
+
+// WithLowPriority marks requests made with ctx as background traffic, such as status polls,
+// that should yield to other requests when the client is at its concurrency limit.
+func WithLowPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, lowPriorityKey{}, true)
+}
+
+func isLowPriority(ctx context.Context) bool {
+	low, _ := ctx.Value(lowPriorityKey{}).(bool)
+	return low
+}
@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// LogSubsystem is the tflog subsystem of the HTTP client. Requests and responses are logged at
+// DEBUG, redacted bodies at TRACE.
+const LogSubsystem = "penguin-http"
+
+const (
+	requestIDHeader = "X-Request-Id"
+	redactedValue   = "***"
+)
+
+// sensitiveBodyFields are masked wherever they appear in logged JSON bodies.
+var sensitiveBodyFields = map[string]bool{
+	"rootloginpassword": true,
+	"password":          true,
+	"token":             true,
+	"cloudinitdata":     true,
+}
+
+type logSubsystemKey struct{}
+
+// withLogSubsystem sets up the HTTP client subsystem on ctx unless an outer call already did, so
+// nested calls such as health probes and retries share it.
+func withLogSubsystem(ctx context.Context) context.Context {
+	if ctx.Value(logSubsystemKey{}) != nil {
+		return ctx
+	}
+	return context.WithValue(tflog.NewSubsystem(ctx, LogSubsystem), logSubsystemKey{}, true)
+}
+
+// authSchemes returns the schemes of the credentials in an Authorization header, e.g.
+// "Bearer, Bearer" for a legacy token plus a JWT, without the credentials themselves.
+func authSchemes(header string) string {
+	if header == "" {
+		return "none"
+	}
+	parts := strings.Split(header, ",")
+	schemes := make([]string, 0, len(parts))
+	for _, part := range parts {
+		scheme, _, _ := strings.Cut(strings.TrimSpace(part), " ")
+		schemes = append(schemes, scheme)
+	}
+	return strings.Join(schemes, ", ")
+}
+
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func logRequest(ctx context.Context, req *http.Request, payload []byte) {
+	tflog.SubsystemDebug(ctx, LogSubsystem, "Sending HTTP request", map[string]any{
+		"http_method": req.Method,
+		"http_path":   req.URL.Path,
+		"http_query":  req.URL.RawQuery,
+		"request_id":  req.Header.Get(requestIDHeader),
+		"http_auth":   authSchemes(req.Header.Get("Authorization")),
+	})
+	if len(payload) > 0 {
+		tflog.SubsystemTrace(ctx, LogSubsystem, "HTTP request body", map[string]any{
+			"request_id": req.Header.Get(requestIDHeader),
+			"http_body":  redactBody(payload),
+		})
+	}
+}
+
+func logResponse(ctx context.Context, req *http.Request, resp *http.Response, body []byte, latency time.Duration) {
+	requestID := resp.Header.Get(requestIDHeader)
+	if requestID == "" {
+		requestID = req.Header.Get(requestIDHeader)
+	}
+	tflog.SubsystemDebug(ctx, LogSubsystem, "Received HTTP response", map[string]any{
+		"http_method":     req.Method,
+		"http_path":       req.URL.Path,
+		"http_status":     resp.StatusCode,
+		"http_latency_ms": latency.Milliseconds(),
+		"request_id":      requestID,
+	})
+	if len(body) > 0 {
+		tflog.SubsystemTrace(ctx, LogSubsystem, "HTTP response body", map[string]any{
+			"request_id": requestID,
+			"http_body":  redactBody(body),
+		})
+	}
+}
+
+func logTransportError(ctx context.Context, req *http.Request, err error, latency time.Duration) {
+	tflog.SubsystemDebug(ctx, LogSubsystem, "HTTP request failed", map[string]any{
+		"http_method":     req.Method,
+		"http_path":       req.URL.Path,
+		"http_latency_ms": latency.Milliseconds(),
+		"request_id":      req.Header.Get(requestIDHeader),
+		"error":           err.Error(),
+	})
+}
+
+// redactBody returns body with sensitive JSON fields masked. Bodies that are not JSON are
+// replaced entirely, since they cannot be inspected field by field.
+func redactBody(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "[non-JSON body redacted]"
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return "[body redacted]"
+	}
+	return string(out)
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if sensitiveBodyFields[strings.ToLower(key)] {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(value)
+		}
+	case []any:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePrometheusText parses the samples of a Prometheus text exposition. Comments, HELP and
+// TYPE lines are skipped; sample timestamps are ignored.
+func parsePrometheusText(raw []byte) ([]MetricSample, error) {
+	var samples []MetricSample
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		sample, err := parseMetricSample(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// parseMetricSample parses `name{label="value",...} value [timestamp]`.
+func parseMetricSample(text string) (MetricSample, error) {
+	end := strings.IndexAny(text, "{ \t")
+	if end <= 0 {
+		return MetricSample{}, fmt.Errorf("malformed sample %q", text)
+	}
+	sample := MetricSample{Name: text[:end], Labels: map[string]string{}}
+	rest := text[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		rest, err = parseMetricLabels(rest[1:], sample.Labels)
+		if err != nil {
+			return MetricSample{}, fmt.Errorf("metric %s: %w", sample.Name, err)
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return MetricSample{}, fmt.Errorf("metric %s: expected a value and an optional timestamp", sample.Name)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return MetricSample{}, fmt.Errorf("metric %s: invalid value %q", sample.Name, fields[0])
+	}
+	sample.Value = value
+	return sample, nil
+}
+
+// parseMetricLabels reads labels up to the closing brace into labels and returns the remainder.
+func parseMetricLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || len(s) < eq+2 || s[eq+1] != '"' {
+			return "", fmt.Errorf("malformed labels %q", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = s[eq+2:]
+
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+			case c == '"':
+				closed = true
+				s = s[i+1:]
+			default:
+				value.WriteByte(c)
+			}
+			if closed {
+				break
+			}
+		}
+		if !closed {
+			return "", fmt.Errorf("unterminated value of label %q", name)
+		}
+		labels[name] = value.String()
+
+		s = strings.TrimLeft(s, " \t")
+		s = strings.TrimPrefix(s, ",")
+	}
+}
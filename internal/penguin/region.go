@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// RegionEndpoint is the Penguin deployment serving one Tencent Cloud region. Empty tokens fall
+// back to the credentials of the regional client's default deployment.
+type RegionEndpoint struct {
+	Endpoint    string
+	LegacyToken string
+	JWT         string
+}
+
+// RegionFromZone derives the region of a zone, e.g. "ap-guangzhou" from "ap-guangzhou-3".
+func RegionFromZone(zone string) string {
+	i := strings.LastIndex(zone, "-")
+	if i < 0 || strings.Trim(zone[i+1:], "0123456789") != "" || i == len(zone)-1 {
+		return zone
+	}
+	return zone[:i]
+}
+
+type regionKey struct{}
+
+// WithRegion routes requests made with ctx to the deployment serving region. It has no effect
+// on clients without per-region endpoints.
+func WithRegion(ctx context.Context, region string) context.Context {
+	if region == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+func regionFromContext(ctx context.Context) string {
+	region, _ := ctx.Value(regionKey{}).(string)
+	return region
+}
+
+// regionRouter holds the per-region deployments of a client.
+type regionRouter struct {
+	clients map[string]*Client
+
+	// vmRegions remembers where virtual machines live, learned from creates and lookups.
+	mu        sync.Mutex
+	vmRegions map[string]string
+}
+
+// newRegionRouter creates a client per region. They share limiter, so the configured limits
+// apply to the provider as a whole rather than to each deployment.
+func newRegionRouter(regions map[string]RegionEndpoint, opts ClientOptions, legacyToken string, jwt string, limiter *requestLimiter) (*regionRouter, error) {
+	if len(regions) == 0 {
+		return nil, nil
+	}
+
+	router := &regionRouter{clients: map[string]*Client{}, vmRegions: map[string]string{}}
+	for _, region := range slices.Sorted(maps.Keys(regions)) {
+		r := regions[region]
+		regionOpts := opts
+		regionOpts.Regions = nil
+		regionOpts.FailoverEndpoints = nil
+		regionToken, regionJWT := legacyToken, jwt
+		if r.LegacyToken != "" || r.JWT != "" {
+			regionToken, regionJWT = r.LegacyToken, r.JWT
+			regionOpts.Credentials = nil
+		}
+		client, err := NewClient(r.Endpoint, regionToken, regionJWT, regionOpts)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint for region %q: %w", region, err)
+		}
+		client.limiter = limiter
+		router.clients[region] = client
+	}
+	return router, nil
+}
+
+// regionClient returns the client that serves requests made with ctx: the deployment of the
+// region in ctx, or c itself when there is none. An error is returned when no deployment can
+// serve the request.
+func (c *Client) regionClient(ctx context.Context) (*Client, error) {
+	if c.regions == nil {
+		return c, nil
+	}
+	region := regionFromContext(ctx)
+	if client, ok := c.regions.clients[region]; ok {
+		return client, nil
+	}
+	if len(c.endpoints) > 0 {
+		return c, nil
+	}
+	if region == "" {
+		return nil, errors.New("request has no region and the provider has no default endpoint; set `endpoint` or use a resource with a zone or region")
+	}
+	return nil, fmt.Errorf("no Penguin endpoint configured for region %q; add it to `endpoint_by_region` or set `endpoint`", region)
+}
+
+// withVirtualMachineRegion adds the region of virtual machine id to ctx unless ctx already has
+// one. Unknown machines are looked up in every deployment. A machine that every deployment
+// reports missing is left to the default endpoint, or fails with ErrNotFound without one; any
+// other lookup error is returned, since the machine may live in that deployment.
+func (c *Client) withVirtualMachineRegion(ctx context.Context, id string) (context.Context, error) {
+	if c.regions == nil || regionFromContext(ctx) != "" {
+		return ctx, nil
+	}
+
+	c.regions.mu.Lock()
+	region, ok := c.regions.vmRegions[id]
+	c.regions.mu.Unlock()
+	if ok {
+		return WithRegion(ctx, region), nil
+	}
+
+	var notFound, lookupErr error
+	for _, region := range slices.Sorted(maps.Keys(c.regions.clients)) {
+		_, err := c.regions.clients[region].GetVirtualMachineStatus(ctx, id)
+		switch {
+		case err == nil:
+			c.rememberVirtualMachineRegion(id, region)
+			return WithRegion(ctx, region), nil
+		case errors.Is(err, ErrNotFound):
+			notFound = err
+		case lookupErr == nil:
+			lookupErr = fmt.Errorf("look up virtual machine %s in region %q: %w", id, region, err)
+		}
+	}
+	if lookupErr != nil {
+		return ctx, lookupErr
+	}
+	if len(c.endpoints) > 0 {
+		return ctx, nil
+	}
+	return ctx, notFound
+}
+
+func (c *Client) rememberVirtualMachineRegion(id string, region string) {
+	if c.regions == nil || id == "" || region == "" {
+		return
+	}
+	c.regions.mu.Lock()
+	defer c.regions.mu.Unlock()
+	c.regions.vmRegions[id] = region
+}
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryMaxWait = 30 * time.Second
+
+	defaultRetryMinWait = 1 * time.Second
+)
+
+// RetryPolicy configures how the client retries transient failures: transport errors and
+// 429, 502, 503 and 504 responses. Only idempotent methods are retried, plus POSTs issued
+// with a context from withRetrySafe.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// MinWait is the base delay of the exponential backoff. Defaults to one second.
+	MinWait time.Duration
+	// MaxWait caps both the backoff and any Retry-After delay. Defaults to DefaultRetryMaxWait.
+	MaxWait time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxRetries < 0 {
+		p.MaxRetries = 0
+	}
+	if p.MinWait <= 0 {
+		p.MinWait = defaultRetryMinWait
+	}
+	if p.MaxWait <= 0 {
+		p.MaxWait = DefaultRetryMaxWait
+	}
+	if p.MaxWait < p.MinWait {
+		p.MinWait = p.MaxWait
+	}
+	return p
+}
+
+// backoff returns the delay before retry number attempt+1. A Retry-After header on 429 and 503
+// responses takes precedence over the exponential backoff.
+func (p RetryPolicy) backoff(attempt int, status int, header http.Header) time.Duration {
+	if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+		if wait, ok := parseRetryAfter(header.Get("Retry-After")); ok {
+			return min(wait, p.MaxWait)
+		}
+	}
+
+	wait := p.MaxWait
+	if attempt < 32 {
+		wait = min(p.MinWait<<attempt, p.MaxWait)
+	}
+	// Equal jitter: half fixed, half random, so concurrent clients spread out.
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+type retrySafeKey struct{}
+
+// withRetrySafe marks requests made with ctx as safe to retry even though they are not
+// idempotent by method, e.g. POSTs that only request a state transition.
+func withRetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
+}
+
+func isRetrySafe(ctx context.Context) bool {
+	safe, _ := ctx.Value(retrySafeKey{}).(bool)
+	return safe
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// TLSOptions customizes how the client verifies the Penguin endpoint and authenticates to it.
+type TLSOptions struct {
+	// CACertPEM holds additional PEM encoded CA certificates trusted next to the system pool.
+	CACertPEM []byte
+	// ClientCertPEM and ClientKeyPEM enable mutual TLS; both must be set together.
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+	// ServerName overrides the host name used to verify the server certificate.
+	ServerName string
+	// InsecureSkipVerify disables server certificate verification entirely.
+	InsecureSkipVerify bool
+}
+
+func (o *TLSOptions) config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if len(o.CACertPEM) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(o.CACertPEM) {
+			return nil, errors.New("CA certificate bundle contains no valid PEM certificates")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if len(o.ClientCertPEM) > 0 || len(o.ClientKeyPEM) > 0 {
+		if len(o.ClientCertPEM) == 0 || len(o.ClientKeyPEM) == 0 {
+			return nil, errors.New("client certificate and client key must be set together")
+		}
+		cert, err := tls.X509KeyPair(o.ClientCertPEM, o.ClientKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func newTLSHTTPClient(opts *TLSOptions) (*http.Client, error) {
+	cfg, err := opts.config()
+	if err != nil {
+		return nil, err
+	}
+	var transport *http.Transport
+	if base, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = base.Clone()
+	} else {
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+	transport.TLSClientConfig = cfg
+	return &http.Client{Timeout: defaultTimeout, Transport: transport}, nil
+}
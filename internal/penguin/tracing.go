@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName identifies spans created by the client.
+const TracerName = "github.com/indexyz/terraform-provider-penguin/internal/penguin"
+
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		// The global provider is a no-op until the process installs one.
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(TracerName)
+}
+
+// spanRoute replaces identifiers in p so that span names stay low-cardinality, e.g.
+// `/tencentcloud/vms/:id/status`.
+func spanRoute(p string) string {
+	segments := strings.Split(p, "/")
+	for i := 1; i < len(segments); i++ {
+		switch segments[i-1] {
+		case "vms", "eips":
+			if segments[i] != "" && segments[i] != "missing" {
+				segments[i] = ":id"
+			}
+		case "images":
+			if segments[i] != "" {
+				segments[i] = ":name"
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func endSpan(span trace.Span, attempts int, err error) {
+	span.SetAttributes(attribute.Int("penguin.attempts", attempts))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// injectTraceContext propagates the active span to the service as `traceparent`. Nothing is
+// added when tracing is disabled, since the span context is then invalid.
+func injectTraceContext(req *http.Request) {
+	propagation.TraceContext{}.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+}
@@ -5,19 +5,30 @@ package penguin
 
 import "fmt"
 
+// APIError is returned for any response with an unexpected status. Use errors.Is with the
+// sentinel errors (ErrNotFound, ErrConflict, ...) to classify it.
 type APIError struct {
 	Status  int    `json:"status"`
 	Message string `json:"message"`
+
+	// Method and Path identify the failed request; Body is the raw (size limited) response.
+	Method string `json:"-"`
+	Path   string `json:"-"`
+	Body   []byte `json:"-"`
 }
 
 func (e *APIError) Error() string {
 	if e == nil {
 		return "penguin API error"
 	}
+	var request string
+	if e.Path != "" {
+		request = fmt.Sprintf(" (%s %s)", e.Method, e.Path)
+	}
 	if e.Message == "" {
-		return fmt.Sprintf("penguin API error %d", e.Status)
+		return fmt.Sprintf("penguin API error %d%s", e.Status, request)
 	}
-	return fmt.Sprintf("penguin API error %d: %s", e.Status, e.Message)
+	return fmt.Sprintf("penguin API error %d%s: %s", e.Status, request, e.Message)
 }
 
 type InternalHealthResponse struct {
@@ -25,6 +36,19 @@ type InternalHealthResponse struct {
 	Database string `json:"database"`
 }
 
+// InternalMetricsResponse is the Prometheus text exposition served by `GET /_internal/metrics`.
+type InternalMetricsResponse struct {
+	Raw     string
+	Samples []MetricSample
+}
+
+// MetricSample is one sample line of a Prometheus text exposition.
+type MetricSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
 type Zone struct {
 	Region     string `json:"region"`
 	RegionName string `json:"regionName"`
@@ -103,12 +127,41 @@ type ResetVirtualMachinePasswordResponse struct {
 	Password string `json:"password"`
 }
 
+// Image is the metadata returned by `GET /tencentcloud/images/:name`. The service does not
+// document the response body, so the fields are a best guess based on the image attributes of
+// the Tencent Cloud DescribeImages API and any of them may be empty.
+type Image struct {
+	ID           string  `json:"imageId"`
+	Name         string  `json:"imageName"`
+	Type         string  `json:"imageType,omitempty"`
+	State        string  `json:"imageState,omitempty"`
+	OSName       string  `json:"osName,omitempty"`
+	Platform     string  `json:"platform,omitempty"`
+	Architecture string  `json:"architecture,omitempty"`
+	SizeGiB      int64   `json:"imageSize,omitempty"`
+	Description  *string `json:"imageDescription,omitempty"`
+	CreatedTime  *string `json:"createdTime,omitempty"`
+}
+
+// MissingVirtualMachine is a virtual machine tracked by Penguin whose Tencent Cloud instance no
+// longer exists.
 type MissingVirtualMachine struct {
 	ID         string `json:"id"`
 	Zone       string `json:"zone"`
 	InstanceID string `json:"instanceId"`
 }
 
+// Instance states reported in VirtualMachineStatus.InstanceState.
+const (
+	InstanceStatePending          = "PENDING"
+	InstanceStateRunning          = "RUNNING"
+	InstanceStateStarting         = "STARTING"
+	InstanceStateStopping         = "STOPPING"
+	InstanceStateStopped          = "STOPPED"
+	InstanceStateRebooting        = "REBOOTING"
+	InstanceStateSuspendOverUsage = "SuspendOverUsage"
+)
+
 type VirtualMachineStatus struct {
 	ID                string   `json:"id"`
 	Zone              string   `json:"zone"`
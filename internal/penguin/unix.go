@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package penguin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// unixSocketHost is the placeholder host of requests sent over a Unix domain socket. It only
+// appears in the Host header, logs and traces.
+const unixSocketHost = "localhost"
+
+// parseUnixEndpoint recognizes Unix domain socket endpoints and returns the socket path and
+// the HTTP base URL to send requests to. Two forms are accepted:
+//
+//	unix:///run/penguin.sock                  the whole path names the socket
+//	http+unix://%2Frun%2Fpenguin.sock/api     the escaped host names the socket, the path is the API base
+//
+// ok is false for any other endpoint. The http+unix form is split by hand because url.Parse
+// rejects escapes in the host.
+func parseUnixEndpoint(endpoint string) (socket string, base *url.URL, ok bool, err error) {
+	switch {
+	case strings.HasPrefix(endpoint, "unix://"):
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			return "", nil, true, fmt.Errorf("parse endpoint: %w", err)
+		}
+		if parsed.Host != "" {
+			return "", nil, true, fmt.Errorf("unix endpoint must be an absolute socket path such as unix:///run/penguin.sock, got host %q", parsed.Host)
+		}
+		socket = parsed.Path
+		base = &url.URL{Scheme: "http", Host: unixSocketHost}
+	case strings.HasPrefix(endpoint, "http+unix://"):
+		host, basePath, _ := strings.Cut(strings.TrimPrefix(endpoint, "http+unix://"), "/")
+		socket, err = url.PathUnescape(host)
+		if err != nil {
+			return "", nil, true, fmt.Errorf("parse socket path: %w", err)
+		}
+		base, err = url.Parse("http://" + unixSocketHost + "/" + basePath)
+		if err != nil {
+			return "", nil, true, fmt.Errorf("parse endpoint: %w", err)
+		}
+	default:
+		return "", nil, false, nil
+	}
+	if socket == "" {
+		return "", nil, true, fmt.Errorf("endpoint %q does not name a socket", endpoint)
+	}
+	return socket, base, true, nil
+}
+
+// newUnixSocketHTTPClient returns a client whose connections all dial socket, regardless of
+// the request host.
+func newUnixSocketHTTPClient(socket string) *http.Client {
+	var transport *http.Transport
+	if base, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = base.Clone()
+	} else {
+		transport = &http.Transport{}
+	}
+	transport.Proxy = nil
+	dialer := &net.Dialer{}
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+	return &http.Client{Timeout: defaultTimeout, Transport: transport}
+}
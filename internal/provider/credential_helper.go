@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
+)
+
+const (
+	credentialHelperTimeout = time.Minute
+
+	// credentialHelperExpirySkew re-invokes the helper shortly before expires_at so that
+	// in-flight requests do not race the expiry.
+	credentialHelperExpirySkew = 30 * time.Second
+
+	maxCredentialHelperStderr = 1024
+)
+
+// credentialHelperOutput is the JSON document the helper prints to stdout. All fields are
+// optional; expires_at is an RFC 3339 timestamp.
+type credentialHelperOutput struct {
+	Endpoint  string `json:"endpoint"`
+	AuthToken string `json:"auth_token"`
+	JWT       string `json:"jwt"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// credentialHelper runs the `credential_helper` command and caches its output until
+// expires_at. It implements penguin.CredentialSource. Tokens set as attributes or environment
+// variables (override) win over the helper, profile tokens (fallback) only fill gaps.
+type credentialHelper struct {
+	command  []string
+	override penguin.Credentials
+	fallback penguin.Credentials
+
+	mu        sync.Mutex
+	current   *credentialHelperOutput
+	expiresAt time.Time
+}
+
+var _ penguin.CredentialSource = &credentialHelper{}
+
+func newCredentialHelper(command []string) (*credentialHelper, error) {
+	if len(command) == 0 || strings.TrimSpace(command[0]) == "" {
+		return nil, errors.New("`credential_helper` must name a command")
+	}
+	return &credentialHelper{command: command}, nil
+}
+
+// Output returns the cached helper output, running the helper when nothing is cached, the output
+// expired, or refresh is set.
+func (h *credentialHelper) Output(ctx context.Context, refresh bool) (credentialHelperOutput, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.current != nil && !refresh && (h.expiresAt.IsZero() || time.Now().Before(h.expiresAt.Add(-credentialHelperExpirySkew))) {
+		return *h.current, nil
+	}
+
+	out, expiresAt, err := h.run(ctx)
+	if err != nil {
+		return credentialHelperOutput{}, err
+	}
+	h.current, h.expiresAt = out, expiresAt
+	return *out, nil
+}
+
+func (h *credentialHelper) Credentials(ctx context.Context, refresh bool) (penguin.Credentials, error) {
+	out, err := h.Output(ctx, refresh)
+	if err != nil {
+		return penguin.Credentials{}, err
+	}
+	return penguin.Credentials{
+		LegacyToken: firstNonEmpty(h.override.LegacyToken, out.AuthToken, h.fallback.LegacyToken),
+		JWT:         firstNonEmpty(h.override.JWT, out.JWT, h.fallback.JWT),
+	}, nil
+}
+
+// run executes the helper. Its stdout carries secrets and is never logged; stderr is only
+// included in errors.
+func (h *credentialHelper) run(ctx context.Context) (*credentialHelperOutput, time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialHelperTimeout)
+	defer cancel()
+
+	tflog.Debug(ctx, "Running credential helper", map[string]any{"command": h.command[0]})
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.command[0], h.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		detail := strings.TrimSpace(stderr.String())
+		if len(detail) > maxCredentialHelperStderr {
+			detail = detail[:maxCredentialHelperStderr] + "..."
+		}
+		if detail != "" {
+			return nil, time.Time{}, fmt.Errorf("credential helper %q failed: %w: %s", h.command[0], err, detail)
+		}
+		return nil, time.Time{}, fmt.Errorf("credential helper %q failed: %w", h.command[0], err)
+	}
+
+	var out credentialHelperOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		// The JSON error never quotes the input, so this cannot leak a token.
+		return nil, time.Time{}, fmt.Errorf("credential helper %q printed invalid JSON: %w", h.command[0], err)
+	}
+	out.Endpoint = strings.TrimSpace(out.Endpoint)
+	out.AuthToken = strings.TrimSpace(out.AuthToken)
+	out.JWT = strings.TrimSpace(out.JWT)
+
+	var expiresAt time.Time
+	if out.ExpiresAt != "" {
+		var err error
+		expiresAt, err = time.Parse(time.RFC3339, out.ExpiresAt)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("credential helper %q printed an invalid expires_at %q; expected an RFC 3339 timestamp", h.command[0], out.ExpiresAt)
+		}
+	}
+
+	tflog.Debug(ctx, "Credential helper succeeded", map[string]any{
+		"command":        h.command[0],
+		"has_endpoint":   out.Endpoint != "",
+		"has_auth_token": out.AuthToken != "",
+		"has_jwt":        out.JWT != "",
+		"expires_at":     out.ExpiresAt,
+	})
+	return &out, expiresAt, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
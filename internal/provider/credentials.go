@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const defaultCredentialsProfile = "default"
+
+// credentialsFileKeys are the settings a credentials profile may hold.
+var credentialsFileKeys = []string{"endpoint", "auth_token", "jwt"}
+
+// credentialsProfile is one section of the credentials file:
+//
+//	[default]
+//	endpoint   = https://penguin.example.com
+//	auth_token = ...
+//	jwt        = ...
+type credentialsProfile struct {
+	Name   string
+	File   string
+	Values map[string]string
+}
+
+// credentialsFilePath returns PENGUIN_CONFIG_FILE or ~/.config/penguin/credentials. explicit
+// reports whether the path was configured rather than defaulted.
+func credentialsFilePath() (file string, explicit bool, err error) {
+	if file := strings.TrimSpace(os.Getenv("PENGUIN_CONFIG_FILE")); file != "" {
+		return file, true, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false, err
+	}
+	return filepath.Join(home, ".config", "penguin", "credentials"), false, nil
+}
+
+// loadCredentialsProfile reads profile from file. A missing file or profile yields nil unless
+// required is set, so the implicit default profile never causes an error.
+func loadCredentialsProfile(file string, profile string, required bool) (*credentialsProfile, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open credentials file: %w", err)
+	}
+	defer f.Close()
+
+	profiles := map[string]*credentialsProfile{}
+	var current *credentialsProfile
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			name := strings.TrimSpace(text[1 : len(text)-1])
+			if name == "" {
+				return nil, fmt.Errorf("%s:%d: empty profile name", file, line)
+			}
+			if _, ok := profiles[name]; ok {
+				return nil, fmt.Errorf("%s:%d: duplicate profile %q", file, line, name)
+			}
+			current = &credentialsProfile{Name: name, File: file, Values: map[string]string{}}
+			profiles[name] = current
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected `key = value` or `[profile]`", file, line)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("%s:%d: setting outside of a [profile] section", file, line)
+		}
+		key = strings.TrimSpace(key)
+		if !slices.Contains(credentialsFileKeys, key) {
+			return nil, fmt.Errorf("%s:%d: unknown setting %q; expected one of %q", file, line, key, credentialsFileKeys)
+		}
+		current.Values[key] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read credentials file: %w", err)
+	}
+
+	p, ok := profiles[profile]
+	if !ok {
+		if required {
+			return nil, fmt.Errorf("profile %q not found in credentials file %s", profile, file)
+		}
+		return nil, nil
+	}
+	return p, nil
+}
+
+// settingOrigin groups setting sources that are configured together. Provider attributes and
+// environment variables are both set by whoever runs Terraform, while a credential helper or a
+// profile supplies an endpoint together with the credentials for it.
+type settingOrigin int
+
+const (
+	originUnset settingOrigin = iota
+	originConfiguration
+	originCredentialHelper
+	originProfile
+)
+
+// settingSource is a provider setting and a description of where its value came from.
+type settingSource struct {
+	Value  string
+	Source string
+	Origin settingOrigin
+}
+
+// resolveSetting picks the first non-empty value, in order of precedence: the provider
+// attribute, the environment variable, then fallbacks such as the credential helper and the
+// credentials profile.
+func resolveSetting(attr types.String, attrName string, envName string, fallbacks ...settingSource) settingSource {
+	if v := strings.TrimSpace(attr.ValueString()); v != "" {
+		return settingSource{Value: v, Source: fmt.Sprintf("provider attribute `%s`", attrName), Origin: originConfiguration}
+	}
+	if v := strings.TrimSpace(os.Getenv(envName)); v != "" {
+		return settingSource{Value: v, Source: fmt.Sprintf("environment variable `%s`", envName), Origin: originConfiguration}
+	}
+	for _, fallback := range fallbacks {
+		if fallback.Value != "" {
+			return fallback
+		}
+	}
+	return settingSource{Source: "unset"}
+}
+
+// profileSetting returns key from profile, which may be nil.
+func profileSetting(profile *credentialsProfile, key string) settingSource {
+	if profile == nil {
+		return settingSource{}
+	}
+	return settingSource{Value: profile.Values[key], Source: fmt.Sprintf("profile %q in %s", profile.Name, profile.File), Origin: originProfile}
+}
+
+// mixedCredentialSources describes the credentials that come from a different origin than the
+// endpoint, e.g. a profile endpoint combined with a `PENGUIN_AUTH_TOKEN` meant for another
+// deployment. It returns an empty string when the sources agree.
+func mixedCredentialSources(endpoint settingSource, legacyToken settingSource, jwt settingSource) string {
+	if endpoint.Origin == originUnset {
+		return ""
+	}
+
+	var mixed []string
+	for _, credential := range []struct {
+		name    string
+		setting settingSource
+	}{
+		{"auth_token", legacyToken},
+		{"jwt", jwt},
+	} {
+		if credential.setting.Origin != originUnset && credential.setting.Origin != endpoint.Origin {
+			mixed = append(mixed, fmt.Sprintf("`%s` from %s", credential.name, credential.setting.Source))
+		}
+	}
+	if len(mixed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("The endpoint comes from %s, but the provider uses %s. Credentials are only valid for the deployment that issued them; "+
+		"set the endpoint and its credentials in the same place if they do not belong together.", endpoint.Source, strings.Join(mixed, " and "))
+}
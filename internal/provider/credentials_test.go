@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
+)
+
+func TestLoadCredentialsProfile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credentials")
+	content := `# Penguin credentials
+[default]
+endpoint = http://127.0.0.1:8080
+
+[prod]
+endpoint   = https://penguin.example.com
+auth_token = prod-token
+jwt        = prod.jwt.value
+`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := loadCredentialsProfile(file, "prod", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.Values["auth_token"] != "prod-token" || profile.Values["endpoint"] != "https://penguin.example.com" {
+		t.Fatalf("unexpected profile: %#v", profile)
+	}
+
+	if _, err := loadCredentialsProfile(file, "staging", true); err == nil || !strings.Contains(err.Error(), `profile "staging" not found`) {
+		t.Fatalf("expected missing profile error, got %v", err)
+	}
+	if profile, err := loadCredentialsProfile(filepath.Join(t.TempDir(), "missing"), defaultCredentialsProfile, false); err != nil || profile != nil {
+		t.Fatalf("expected implicit default profile to be optional, got %#v, %v", profile, err)
+	}
+
+	t.Run("precedence", func(t *testing.T) {
+		t.Setenv("PENGUIN_AUTH_TOKEN", "env-token")
+		t.Setenv("PENGUIN_JWT", "")
+
+		endpoint := resolveSetting(types.StringValue("http://attr"), "endpoint", "PENGUIN_ENDPOINT", profileSetting(profile, "endpoint"))
+		if endpoint.Value != "http://attr" || endpoint.Source != "provider attribute `endpoint`" {
+			t.Fatalf("unexpected endpoint: %#v", endpoint)
+		}
+		token := resolveSetting(types.StringNull(), "auth_token", "PENGUIN_AUTH_TOKEN", profileSetting(profile, "auth_token"))
+		if token.Value != "env-token" || token.Source != "environment variable `PENGUIN_AUTH_TOKEN`" {
+			t.Fatalf("unexpected auth_token: %#v", token)
+		}
+		jwt := resolveSetting(types.StringNull(), "jwt", "PENGUIN_JWT", profileSetting(profile, "jwt"))
+		if jwt.Value != "prod.jwt.value" || !strings.HasPrefix(jwt.Source, `profile "prod" in `) {
+			t.Fatalf("unexpected jwt: %#v", jwt)
+		}
+
+		// Attributes and environment variables are configured together; the profile JWT is not.
+		if detail := mixedCredentialSources(endpoint, token, jwt); !strings.Contains(detail, "`jwt` from profile") || strings.Contains(detail, "auth_token") {
+			t.Fatalf("expected only the profile jwt to be reported, got %q", detail)
+		}
+		profileEndpoint := profileSetting(profile, "endpoint")
+		if detail := mixedCredentialSources(profileEndpoint, token, jwt); !strings.Contains(detail, "`auth_token` from environment variable `PENGUIN_AUTH_TOKEN`") {
+			t.Fatalf("expected the environment token to be reported for a profile endpoint, got %q", detail)
+		}
+		if detail := mixedCredentialSources(profileEndpoint, profileSetting(profile, "auth_token"), jwt); detail != "" {
+			t.Fatalf("expected no warning for a complete profile, got %q", detail)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "credentials")
+		if err := os.WriteFile(bad, []byte("[default]\npassword = x\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadCredentialsProfile(bad, defaultCredentialsProfile, false); err == nil || !strings.Contains(err.Error(), ":2: unknown setting") {
+			t.Fatalf("expected unknown setting error, got %v", err)
+		}
+	})
+}
+
+func TestCredentialHelper(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "calls")
+	// Each run appends to the counter file and prints a token naming the run.
+	script := `echo x >> "$1"; n=$(wc -l < "$1" | tr -d ' '); printf '{"endpoint":"http://helper","jwt":"jwt-%s","expires_at":"%s"}' "$n" "$2"`
+
+	run := func(t *testing.T, expiresAt string) *credentialHelper {
+		t.Helper()
+		_ = os.Remove(counter)
+		helper, err := newCredentialHelper([]string{"sh", "-c", script, "helper", counter, expiresAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return helper
+	}
+
+	t.Run("cached until refresh", func(t *testing.T) {
+		helper := run(t, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		helper.override = penguin.Credentials{LegacyToken: "from-env"}
+
+		for range 2 {
+			creds, err := helper.Credentials(context.Background(), false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if creds.JWT != "jwt-1" || creds.LegacyToken != "from-env" {
+				t.Fatalf("unexpected credentials: %#v", creds)
+			}
+		}
+
+		creds, err := helper.Credentials(context.Background(), true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if creds.JWT != "jwt-2" {
+			t.Fatalf("expected refreshed credentials, got %#v", creds)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		helper := run(t, time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+		for want := 1; want <= 2; want++ {
+			creds, err := helper.Credentials(context.Background(), false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if creds.JWT != fmt.Sprintf("jwt-%d", want) {
+				t.Fatalf("expected helper to run again after expiry, got %#v", creds)
+			}
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		helper, err := newCredentialHelper([]string{"sh", "-c", "echo vault sealed >&2; exit 3"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := helper.Credentials(context.Background(), false); err == nil || !strings.Contains(err.Error(), "vault sealed") {
+			t.Fatalf("expected helper stderr in error, got %v", err)
+		}
+	})
+}
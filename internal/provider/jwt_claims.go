@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
+)
+
+const defaultJWTClaimsTTLMinutes = 60
+
+// JWTClaimsModel describes the provider `jwt_claims` block.
+type JWTClaimsModel struct {
+	AllowedZones         types.List  `tfsdk:"allowed_zones"`
+	AllowedInstanceTypes types.List  `tfsdk:"allowed_instance_types"`
+	MaxBandwidthMbps     types.Int64 `tfsdk:"max_bandwidth_mbps"`
+	MaxTransferKB        types.Int64 `tfsdk:"max_transfer_kb"`
+	ProjectID            types.Int64 `tfsdk:"project_id"`
+	TTLMinutes           types.Int64 `tfsdk:"ttl_minutes"`
+}
+
+func jwtClaimsBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Scope every request by a JWT that the provider issues itself via `POST /auth/jwt`, authenticated with `auth_token`. " +
+			"The provider sends `auth_token` together with the JWT and re-issues the JWT shortly before it expires. Cannot be combined with `jwt`, `credential_helper` or `endpoint_by_region`.",
+		Attributes: map[string]schema.Attribute{
+			"allowed_zones": schema.ListAttribute{
+				MarkdownDescription: "Zones the JWT allows instances to be created in.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"allowed_instance_types": schema.ListAttribute{
+				MarkdownDescription: "Instance types the JWT allows.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"max_bandwidth_mbps": schema.Int64Attribute{
+				MarkdownDescription: "Maximum bandwidth limit in Mbps the JWT allows.",
+				Optional:            true,
+			},
+			"max_transfer_kb": schema.Int64Attribute{
+				MarkdownDescription: "Maximum transfer quota in KB the JWT allows.",
+				Optional:            true,
+			},
+			"project_id": schema.Int64Attribute{
+				MarkdownDescription: "Project the JWT is bound to.",
+				Optional:            true,
+			},
+			"ttl_minutes": schema.Int64Attribute{
+				MarkdownDescription: "Lifetime of each issued JWT in minutes. Defaults to `60`.",
+				Optional:            true,
+			},
+		},
+	}
+}
+
+func (m JWTClaimsModel) unknown() bool {
+	return m.AllowedZones.IsUnknown() || m.AllowedInstanceTypes.IsUnknown() || m.MaxBandwidthMbps.IsUnknown() ||
+		m.MaxTransferKB.IsUnknown() || m.ProjectID.IsUnknown() || m.TTLMinutes.IsUnknown()
+}
+
+func (m JWTClaimsModel) request(ctx context.Context) (penguin.IssueJWTRequest, diag.Diagnostics) {
+	ttl := types.Int64Value(defaultJWTClaimsTTLMinutes)
+	if !m.TTLMinutes.IsNull() {
+		ttl = m.TTLMinutes
+	}
+	return issueJWTRequest(ctx, ttl, m.MaxTransferKB, m.MaxBandwidthMbps, m.ProjectID, m.AllowedInstanceTypes, m.AllowedZones)
+}
+
+// issueJWTRequest converts known claim values into an IssueJWTRequest; null values are omitted.
+func issueJWTRequest(ctx context.Context, ttlMinutes, maxTransferKB, maxBandwidthMbps, projectID types.Int64, allowedInstanceTypes, allowedZones types.List) (penguin.IssueJWTRequest, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	request := penguin.IssueJWTRequest{
+		TTLMinutes:       ttlMinutes.ValueInt64(),
+		MaxTransferKB:    maxTransferKB.ValueInt64Pointer(),
+		MaxBandwidthMbps: maxBandwidthMbps.ValueInt64Pointer(),
+		ProjectID:        projectID.ValueInt64Pointer(),
+	}
+
+	if !allowedInstanceTypes.IsNull() {
+		diags.Append(allowedInstanceTypes.ElementsAs(ctx, &request.AllowedInstanceTypes, false)...)
+	}
+	if !allowedZones.IsNull() {
+		diags.Append(allowedZones.ElementsAs(ctx, &request.AllowedZones, false)...)
+	}
+	return request, diags
+}
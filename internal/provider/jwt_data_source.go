@@ -106,33 +106,8 @@ func (d *JWTDataSource) Read(ctx context.Context, req datasource.ReadRequest, re
 		return
 	}
 
-	request := penguin.IssueJWTRequest{
-		TTLMinutes: config.TTLMinutes.ValueInt64(),
-	}
-
-	if !config.MaxTransferKB.IsNull() {
-		v := config.MaxTransferKB.ValueInt64()
-		request.MaxTransferKB = &v
-	}
-	if !config.MaxBandwidthMbps.IsNull() {
-		v := config.MaxBandwidthMbps.ValueInt64()
-		request.MaxBandwidthMbps = &v
-	}
-	if !config.ProjectID.IsNull() {
-		v := config.ProjectID.ValueInt64()
-		request.ProjectID = &v
-	}
-
-	if !config.AllowedInstanceTypes.IsNull() {
-		var values []string
-		resp.Diagnostics.Append(config.AllowedInstanceTypes.ElementsAs(ctx, &values, false)...)
-		request.AllowedInstanceTypes = values
-	}
-	if !config.AllowedZones.IsNull() {
-		var values []string
-		resp.Diagnostics.Append(config.AllowedZones.ElementsAs(ctx, &values, false)...)
-		request.AllowedZones = values
-	}
+	request, diags := issueJWTRequest(ctx, config.TTLMinutes, config.MaxTransferKB, config.MaxBandwidthMbps, config.ProjectID, config.AllowedInstanceTypes, config.AllowedZones)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -8,12 +8,17 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
 )
 
@@ -29,13 +34,42 @@ type PenguinProvider struct {
 	commit  string
 }
 
-// PenguinProviderModel describes the provider configuration model.
-type PenguinProviderModel struct {
+// EndpointByRegionModel describes one `endpoint_by_region` entry.
+type EndpointByRegionModel struct {
 	Endpoint  types.String `tfsdk:"endpoint"`
 	AuthToken types.String `tfsdk:"auth_token"`
 	JWT       types.String `tfsdk:"jwt"`
 }
 
+// PenguinProviderModel describes the provider configuration model.
+type PenguinProviderModel struct {
+	Endpoint  types.String `tfsdk:"endpoint"`
+	Endpoints types.List   `tfsdk:"endpoints"`
+	// EndpointByRegion maps regions to EndpointByRegionModel values.
+	EndpointByRegion types.Map    `tfsdk:"endpoint_by_region"`
+	AuthToken        types.String `tfsdk:"auth_token"`
+	JWT              types.String `tfsdk:"jwt"`
+	Profile          types.String `tfsdk:"profile"`
+
+	CredentialHelper types.List   `tfsdk:"credential_helper"`
+	JWTClaims        types.Object `tfsdk:"jwt_claims"`
+
+	MaxRetries   types.Int64  `tfsdk:"max_retries"`
+	RetryMaxWait types.String `tfsdk:"retry_max_wait"`
+
+	RequestsPerSecond     types.Float64 `tfsdk:"requests_per_second"`
+	MaxConcurrentRequests types.Int64   `tfsdk:"max_concurrent_requests"`
+
+	HTTPTraceFile types.String `tfsdk:"http_trace_file"`
+
+	CACertPEM          types.String `tfsdk:"ca_cert_pem"`
+	CACertFile         types.String `tfsdk:"ca_cert_file"`
+	ClientCertPEM      types.String `tfsdk:"client_cert_pem"`
+	ClientKeyPEM       types.String `tfsdk:"client_key_pem"`
+	TLSServerName      types.String `tfsdk:"tls_server_name"`
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+}
+
 func (p *PenguinProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "penguin"
 	resp.Version = p.version
@@ -45,9 +79,39 @@ func (p *PenguinProvider) Schema(ctx context.Context, req provider.SchemaRequest
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
 			"endpoint": schema.StringAttribute{
-				MarkdownDescription: "Penguin service base URL, e.g. `http://127.0.0.1:8080`. Can also be set via `PENGUIN_ENDPOINT`.",
+				MarkdownDescription: "Penguin service base URL, e.g. `http://127.0.0.1:8080`. A local agent listening on a Unix domain socket can be reached with `unix:///run/penguin.sock`, or `http+unix://%2Frun%2Fpenguin.sock/base-path` when the API is served below a path. Can also be set via `PENGUIN_ENDPOINT`.",
 				Optional:            true,
 			},
+			"endpoints": schema.ListAttribute{
+				MarkdownDescription: "Ordered list of Penguin replicas to use instead of `endpoint`. Requests go to the first healthy replica, as determined by `GET /health`. " +
+					"When a request fails with a connection error, 502 or 503 and is safe to repeat, it is retried on the next replica. A replica that fails three requests in a row is skipped for 30 seconds and health checked before it receives traffic again. Requests that are not, such as creating a virtual machine, are never replayed on another replica.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"endpoint_by_region": schema.MapNestedAttribute{
+				MarkdownDescription: "Separate Penguin deployments keyed by Tencent Cloud region, e.g. `ap-guangzhou`. Requests are routed by the region of the resource: " +
+					"the region of `zone` for virtual machines, and `region` for elastic IPs and bandwidth packages. Requests without a region, or for other regions, go to `endpoint`, which becomes optional. " +
+					"`penguin_tencentcloud_zones` lists the zones of all deployments. Resources that only know a virtual machine ID find its deployment by querying each one. Cannot be combined with `jwt_claims`.",
+				Optional: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"endpoint": schema.StringAttribute{
+							MarkdownDescription: "Base URL of the deployment serving the region.",
+							Required:            true,
+						},
+						"auth_token": schema.StringAttribute{
+							MarkdownDescription: "Legacy bearer token for this deployment. When neither `auth_token` nor `jwt` is set, the provider credentials are used.",
+							Optional:            true,
+							Sensitive:           true,
+						},
+						"jwt": schema.StringAttribute{
+							MarkdownDescription: "JWT for this deployment.",
+							Optional:            true,
+							Sensitive:           true,
+						},
+					},
+				},
+			},
 			"auth_token": schema.StringAttribute{
 				MarkdownDescription: "Legacy bearer token used to authenticate to Penguin. Can also be set via `PENGUIN_AUTH_TOKEN`.",
 				Optional:            true,
@@ -58,6 +122,67 @@ func (p *PenguinProvider) Schema(ctx context.Context, req provider.SchemaRequest
 				Optional:            true,
 				Sensitive:           true,
 			},
+			"profile": schema.StringAttribute{
+				MarkdownDescription: "Name of the profile in the credentials file that supplies `endpoint`, `auth_token` and `jwt` when they are set neither as attributes nor via environment variables. " +
+					"The file defaults to `~/.config/penguin/credentials` and can be moved with `PENGUIN_CONFIG_FILE`. Can also be set via `PENGUIN_PROFILE`; defaults to `default`. " +
+					"The provider warns when the endpoint and its credentials come from different sources, e.g. a profile endpoint with `PENGUIN_AUTH_TOKEN`.",
+				Optional: true,
+			},
+			"credential_helper": schema.ListAttribute{
+				MarkdownDescription: "Command and arguments of a program that prints credentials as JSON, e.g. `[\"penguin-vault-helper\", \"--role\", \"ci\"]`. " +
+					"The output is an object with optional `endpoint`, `auth_token`, `jwt` and `expires_at` (RFC 3339) fields. The helper is run again once `expires_at` passes or when the API responds with 401. " +
+					"Values set as attributes or environment variables take precedence over the helper, which takes precedence over the credentials file. The output is never logged.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum number of retries for transient API failures (connection errors and HTTP 429, 502, 503, 504). Only idempotent requests and POSTs that are safe to repeat, such as start and shutdown, are retried. Defaults to `%d`; `0` disables retries.", penguin.DefaultMaxRetries),
+				Optional:            true,
+			},
+			"retry_max_wait": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Maximum delay between retries as a Go duration, e.g. `10s`. Also caps `Retry-After` delays requested by the service. Defaults to `%s`.", penguin.DefaultRetryMaxWait),
+				Optional:            true,
+			},
+			"requests_per_second": schema.Float64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum rate of API requests shared by all resources and data sources. Defaults to `%d`; `0` disables rate limiting.", penguin.DefaultRequestsPerSecond),
+				Optional:            true,
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum number of API requests in flight at once. Status polls never take the last free slot, so other operations keep making progress. Defaults to `%d`; `0` disables the limit.", penguin.DefaultMaxConcurrentRequests),
+				Optional:            true,
+			},
+			"http_trace_file": schema.StringAttribute{
+				MarkdownDescription: "Path of a HAR 1.2 file that records every Penguin API request and response, with credentials, passwords, tokens and `cloud_init_data` redacted. Each provider process starts the file afresh, and every entry is written as soon as the response arrives. Intended for bug reports. Can also be set via `PENGUIN_HTTP_TRACE_FILE`.",
+				Optional:            true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded CA certificates trusted in addition to the system pool when verifying the endpoint, e.g. for a private CA.",
+				Optional:            true,
+			},
+			"ca_cert_file": schema.StringAttribute{
+				MarkdownDescription: "Path of a PEM file with CA certificates trusted in addition to the system pool. Can be combined with `ca_cert_pem`.",
+				Optional:            true,
+			},
+			"client_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded client certificate for mutual TLS. Requires `client_key_pem`.",
+				Optional:            true,
+			},
+			"client_key_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded private key of `client_cert_pem`.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"tls_server_name": schema.StringAttribute{
+				MarkdownDescription: "Host name used to verify the server certificate instead of the host in `endpoint`.",
+				Optional:            true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				MarkdownDescription: "Disable verification of the server certificate. **Insecure**: credentials can be intercepted; use only for local testing.",
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"jwt_claims": jwtClaimsBlock(),
 		},
 	}
 }
@@ -65,46 +190,159 @@ func (p *PenguinProvider) Schema(ctx context.Context, req provider.SchemaRequest
 func (p *PenguinProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
 	var data PenguinProviderModel
 
+	resp.Diagnostics.Append(setupTelemetry(ctx, p.version)...)
 	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	if data.Endpoint.IsUnknown() || data.AuthToken.IsUnknown() || data.JWT.IsUnknown() {
+	if data.Endpoint.IsUnknown() || data.Endpoints.IsUnknown() || data.EndpointByRegion.IsUnknown() || data.AuthToken.IsUnknown() || data.JWT.IsUnknown() || data.Profile.IsUnknown() || data.CredentialHelper.IsUnknown() || data.JWTClaims.IsUnknown() || data.MaxRetries.IsUnknown() || data.RetryMaxWait.IsUnknown() ||
+		data.RequestsPerSecond.IsUnknown() || data.MaxConcurrentRequests.IsUnknown() || data.HTTPTraceFile.IsUnknown() ||
+		data.CACertPEM.IsUnknown() || data.CACertFile.IsUnknown() || data.ClientCertPEM.IsUnknown() || data.ClientKeyPEM.IsUnknown() ||
+		data.TLSServerName.IsUnknown() || data.InsecureSkipVerify.IsUnknown() {
 		resp.Diagnostics.AddError(
 			"Unknown Penguin Provider Configuration",
-			"Provider configuration values must be known during planning. Check for unknown values in `endpoint`, `auth_token`, or `jwt`.",
+			"Provider configuration values must be known during planning. Check for unknown values in `endpoint`, `endpoints`, `endpoint_by_region`, `auth_token`, `jwt`, `profile`, `credential_helper`, `jwt_claims`, `max_retries`, `retry_max_wait`, `requests_per_second`, `max_concurrent_requests`, `http_trace_file`, or the TLS settings.",
 		)
 		return
 	}
 
-	endpoint := strings.TrimSpace(data.Endpoint.ValueString())
-	if endpoint == "" {
-		endpoint = strings.TrimSpace(os.Getenv("PENGUIN_ENDPOINT"))
+	profile := loadConfiguredCredentialsProfile(ctx, data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	helper, helperOutput := startCredentialHelper(ctx, data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-	legacyToken := strings.TrimSpace(data.AuthToken.ValueString())
-	if legacyToken == "" {
-		legacyToken = strings.TrimSpace(os.Getenv("PENGUIN_AUTH_TOKEN"))
+	helperSetting := func(value string) settingSource {
+		return settingSource{Value: value, Source: "`credential_helper` output", Origin: originCredentialHelper}
 	}
-	jwt := strings.TrimSpace(data.JWT.ValueString())
-	if jwt == "" {
-		jwt = strings.TrimSpace(os.Getenv("PENGUIN_JWT"))
+
+	var failoverEndpoints []string
+	endpointSetting := resolveSetting(data.Endpoint, "endpoint", "PENGUIN_ENDPOINT", helperSetting(helperOutput.Endpoint), profileSetting(profile, "endpoint"))
+	if !data.Endpoints.IsNull() {
+		var endpoints []string
+		resp.Diagnostics.Append(data.Endpoints.ElementsAs(ctx, &endpoints, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if !data.Endpoint.IsNull() || len(endpoints) == 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("endpoints"), "Invalid endpoints", "`endpoints` must list at least one endpoint and cannot be combined with `endpoint`.")
+			return
+		}
+		endpointSetting = settingSource{Value: strings.TrimSpace(endpoints[0]), Source: "provider attribute `endpoints`", Origin: originConfiguration}
+		for _, e := range endpoints[1:] {
+			failoverEndpoints = append(failoverEndpoints, strings.TrimSpace(e))
+		}
 	}
+	legacyTokenSetting := resolveSetting(data.AuthToken, "auth_token", "PENGUIN_AUTH_TOKEN", helperSetting(helperOutput.AuthToken), profileSetting(profile, "auth_token"))
+	jwtSetting := resolveSetting(data.JWT, "jwt", "PENGUIN_JWT", helperSetting(helperOutput.JWT), profileSetting(profile, "jwt"))
+	tflog.Info(ctx, "Resolved Penguin credentials", map[string]any{
+		"endpoint_source":   endpointSetting.Source,
+		"auth_token_source": legacyTokenSetting.Source,
+		"jwt_source":        jwtSetting.Source,
+	})
+	if detail := mixedCredentialSources(endpointSetting, legacyTokenSetting, jwtSetting); detail != "" {
+		resp.Diagnostics.AddWarning("Penguin endpoint and credentials come from different sources", detail)
+	}
+	endpoint, legacyToken, jwt := endpointSetting.Value, legacyTokenSetting.Value, jwtSetting.Value
 
-	if endpoint == "" {
+	traceFile := strings.TrimSpace(data.HTTPTraceFile.ValueString())
+	if traceFile == "" {
+		traceFile = strings.TrimSpace(os.Getenv("PENGUIN_HTTP_TRACE_FILE"))
+	}
+
+	regions := regionEndpointsFromConfig(ctx, data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if endpoint == "" && len(regions) == 0 {
 		resp.Diagnostics.AddError(
 			"Missing Penguin Endpoint",
-			"Set provider attribute `endpoint` or environment variable `PENGUIN_ENDPOINT`.",
+			"Set provider attribute `endpoint`, `endpoints` or `endpoint_by_region`, environment variable `PENGUIN_ENDPOINT`, or `endpoint` in a credentials file profile.",
 		)
 		return
 	}
 
-	client, err := penguin.NewClient(endpoint, legacyToken, jwt, penguin.ClientOptions{
-		UserAgent: fmt.Sprintf("terraform-provider-penguin/%s (%s)", p.version, p.commit),
-	})
+	retry := penguin.RetryPolicy{MaxRetries: penguin.DefaultMaxRetries}
+	if !data.MaxRetries.IsNull() {
+		if data.MaxRetries.ValueInt64() < 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_retries"), "Invalid max_retries", "`max_retries` must not be negative.")
+			return
+		}
+		retry.MaxRetries = int(data.MaxRetries.ValueInt64())
+	}
+	if !data.RetryMaxWait.IsNull() {
+		wait, err := time.ParseDuration(data.RetryMaxWait.ValueString())
+		if err != nil || wait <= 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("retry_max_wait"), "Invalid retry_max_wait", fmt.Sprintf("`retry_max_wait` must be a positive Go duration such as `30s`, got %q.", data.RetryMaxWait.ValueString()))
+			return
+		}
+		retry.MaxWait = wait
+	}
+
+	requestsPerSecond := float64(penguin.DefaultRequestsPerSecond)
+	if !data.RequestsPerSecond.IsNull() {
+		requestsPerSecond = data.RequestsPerSecond.ValueFloat64()
+		if requestsPerSecond < 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("requests_per_second"), "Invalid requests_per_second", "`requests_per_second` must not be negative.")
+			return
+		}
+	}
+	maxConcurrent := int64(penguin.DefaultMaxConcurrentRequests)
+	if !data.MaxConcurrentRequests.IsNull() {
+		maxConcurrent = data.MaxConcurrentRequests.ValueInt64()
+		if maxConcurrent < 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_concurrent_requests"), "Invalid max_concurrent_requests", "`max_concurrent_requests` must not be negative.")
+			return
+		}
+	}
+
+	tlsOptions := tlsOptionsFromConfig(data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var credentials penguin.CredentialSource
+	if helper != nil {
+		helper.override = penguin.Credentials{
+			LegacyToken: resolveSetting(data.AuthToken, "auth_token", "PENGUIN_AUTH_TOKEN").Value,
+			JWT:         resolveSetting(data.JWT, "jwt", "PENGUIN_JWT").Value,
+		}
+		helper.fallback = penguin.Credentials{
+			LegacyToken: profileSetting(profile, "auth_token").Value,
+			JWT:         profileSetting(profile, "jwt").Value,
+		}
+		credentials = helper
+	}
+
+	clientOptions := penguin.ClientOptions{
+		UserAgent:             fmt.Sprintf("terraform-provider-penguin/%s (%s)", p.version, p.commit),
+		Retry:                 retry,
+		RequestsPerSecond:     requestsPerSecond,
+		MaxConcurrentRequests: int(maxConcurrent),
+		CacheTTL:              penguin.DefaultCacheTTL,
+		TraceFile:             traceFile,
+		TLS:                   tlsOptions,
+		FailoverEndpoints:     failoverEndpoints,
+		Regions:               regions,
+		Credentials:           credentials,
+	}
+
+	if !data.JWTClaims.IsNull() {
+		clientOptions.Credentials = newConfiguredJWTIssuer(ctx, data, endpoint, legacyTokenSetting, jwtSetting, clientOptions, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	client, err := penguin.NewClient(endpoint, legacyToken, jwt, clientOptions)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create Penguin client", err.Error())
+		resp.Diagnostics.AddError("Failed to create Penguin client", fmt.Sprintf("%s (endpoint from %s)", err, endpointSetting.Source))
 		return
 	}
 
@@ -112,11 +350,198 @@ func (p *PenguinProvider) Configure(ctx context.Context, req provider.ConfigureR
 	resp.ResourceData = client
 }
 
+// regionEndpointsFromConfig converts `endpoint_by_region` for penguin.ClientOptions.
+func regionEndpointsFromConfig(ctx context.Context, data PenguinProviderModel, diags *diag.Diagnostics) map[string]penguin.RegionEndpoint {
+	if data.EndpointByRegion.IsNull() {
+		return nil
+	}
+
+	var entries map[string]EndpointByRegionModel
+	diags.Append(data.EndpointByRegion.ElementsAs(ctx, &entries, false)...)
+	if diags.HasError() {
+		return nil
+	}
+
+	regions := make(map[string]penguin.RegionEndpoint, len(entries))
+	for region, entry := range entries {
+		if strings.TrimSpace(region) == "" || strings.TrimSpace(entry.Endpoint.ValueString()) == "" {
+			diags.AddAttributeError(path.Root("endpoint_by_region"), "Invalid endpoint_by_region", "Every `endpoint_by_region` entry needs a region key and an `endpoint`.")
+			return nil
+		}
+		regions[region] = penguin.RegionEndpoint{
+			Endpoint:    strings.TrimSpace(entry.Endpoint.ValueString()),
+			LegacyToken: strings.TrimSpace(entry.AuthToken.ValueString()),
+			JWT:         strings.TrimSpace(entry.JWT.ValueString()),
+		}
+	}
+	return regions
+}
+
+// newConfiguredJWTIssuer issues the first JWT for the `jwt_claims` block so that configuration
+// errors surface during Configure rather than on the first API call.
+func newConfiguredJWTIssuer(ctx context.Context, data PenguinProviderModel, endpoint string, legacyToken settingSource, jwt settingSource, opts penguin.ClientOptions, diags *diag.Diagnostics) *penguin.JWTIssuer {
+	var claims JWTClaimsModel
+	diags.Append(data.JWTClaims.As(ctx, &claims, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return nil
+	}
+	if claims.unknown() {
+		diags.AddAttributeError(path.Root("jwt_claims"), "Unknown jwt_claims", "All `jwt_claims` values must be known during planning.")
+		return nil
+	}
+	if !data.CredentialHelper.IsNull() {
+		diags.AddAttributeError(path.Root("jwt_claims"), "Conflicting credentials", "`jwt_claims` cannot be combined with `credential_helper`.")
+		return nil
+	}
+	if !data.EndpointByRegion.IsNull() {
+		diags.AddAttributeError(path.Root("jwt_claims"), "Conflicting credentials",
+			"`jwt_claims` issues its JWT from `endpoint`, which other Penguin deployments do not accept, so it cannot be combined with `endpoint_by_region`. Set `jwt` for each region instead.")
+		return nil
+	}
+	if jwt.Value != "" {
+		diags.AddAttributeError(path.Root("jwt_claims"), "Conflicting credentials", fmt.Sprintf("`jwt_claims` issues its own JWT, but a JWT is also set by %s.", jwt.Source))
+		return nil
+	}
+	if legacyToken.Value == "" {
+		diags.AddAttributeError(path.Root("jwt_claims"), "Missing Penguin auth token", "`jwt_claims` requires `auth_token` (or `PENGUIN_AUTH_TOKEN`) to issue JWTs.")
+		return nil
+	}
+
+	request, requestDiags := claims.request(ctx)
+	diags.Append(requestDiags...)
+	if diags.HasError() {
+		return nil
+	}
+	if request.TTLMinutes <= 0 {
+		diags.AddAttributeError(path.Root("jwt_claims").AtName("ttl_minutes"), "Invalid ttl_minutes", "`ttl_minutes` must be positive.")
+		return nil
+	}
+
+	if endpoint == "" {
+		diags.AddAttributeError(path.Root("jwt_claims"), "Missing Penguin Endpoint", "`jwt_claims` issues JWTs from `endpoint`, which must be set.")
+		return nil
+	}
+
+	// The issuing client authenticates with the legacy token alone, against the default endpoint.
+	opts.Credentials = nil
+	opts.Regions = nil
+	issuingClient, err := penguin.NewClient(endpoint, legacyToken.Value, "", opts)
+	if err != nil {
+		diags.AddError("Failed to create Penguin client", err.Error())
+		return nil
+	}
+	issuer := penguin.NewJWTIssuer(issuingClient, legacyToken.Value, request)
+	if _, err := issuer.Credentials(ctx, false); err != nil {
+		diags.AddAttributeError(path.Root("jwt_claims"), "Failed to issue JWT", err.Error())
+		return nil
+	}
+	return issuer
+}
+
+// loadConfiguredCredentialsProfile loads the selected credentials file profile. The profile is
+// selected by the `profile` attribute, then PENGUIN_PROFILE, then "default"; only an explicitly
+// selected profile or file has to exist.
+func loadConfiguredCredentialsProfile(ctx context.Context, data PenguinProviderModel, diags *diag.Diagnostics) *credentialsProfile {
+	name, required := strings.TrimSpace(data.Profile.ValueString()), true
+	if name == "" {
+		name = strings.TrimSpace(os.Getenv("PENGUIN_PROFILE"))
+	}
+	if name == "" {
+		name, required = defaultCredentialsProfile, false
+	}
+
+	file, explicitFile, err := credentialsFilePath()
+	if err != nil {
+		if required {
+			diags.AddAttributeError(path.Root("profile"), "Failed to locate credentials file", err.Error())
+		}
+		return nil
+	}
+
+	profile, err := loadCredentialsProfile(file, name, required || explicitFile)
+	if err != nil {
+		diags.AddAttributeError(path.Root("profile"), "Failed to load credentials profile", err.Error())
+		return nil
+	}
+	if profile != nil {
+		tflog.Debug(ctx, "Loaded Penguin credentials profile", map[string]any{"profile": profile.Name, "credentials_file": profile.File})
+	}
+	return profile
+}
+
+// startCredentialHelper runs the configured credential helper once so that its endpoint can be
+// used to create the client. It returns a nil helper when none is configured.
+func startCredentialHelper(ctx context.Context, data PenguinProviderModel, diags *diag.Diagnostics) (*credentialHelper, credentialHelperOutput) {
+	if data.CredentialHelper.IsNull() {
+		return nil, credentialHelperOutput{}
+	}
+
+	var command []string
+	diags.Append(data.CredentialHelper.ElementsAs(ctx, &command, false)...)
+	if diags.HasError() {
+		return nil, credentialHelperOutput{}
+	}
+	helper, err := newCredentialHelper(command)
+	if err != nil {
+		diags.AddAttributeError(path.Root("credential_helper"), "Invalid credential_helper", err.Error())
+		return nil, credentialHelperOutput{}
+	}
+	out, err := helper.Output(ctx, false)
+	if err != nil {
+		diags.AddAttributeError(path.Root("credential_helper"), "Credential helper failed", err.Error())
+		return nil, credentialHelperOutput{}
+	}
+	return helper, out
+}
+
+// tlsOptionsFromConfig returns nil when no TLS attribute is set, keeping the default client.
+func tlsOptionsFromConfig(data PenguinProviderModel, diags *diag.Diagnostics) *penguin.TLSOptions {
+	if data.CACertPEM.IsNull() && data.CACertFile.IsNull() && data.ClientCertPEM.IsNull() && data.ClientKeyPEM.IsNull() &&
+		data.TLSServerName.IsNull() && data.InsecureSkipVerify.IsNull() {
+		return nil
+	}
+
+	opts := &penguin.TLSOptions{
+		CACertPEM:          []byte(data.CACertPEM.ValueString()),
+		ClientCertPEM:      []byte(data.ClientCertPEM.ValueString()),
+		ClientKeyPEM:       []byte(data.ClientKeyPEM.ValueString()),
+		ServerName:         strings.TrimSpace(data.TLSServerName.ValueString()),
+		InsecureSkipVerify: data.InsecureSkipVerify.ValueBool(),
+	}
+
+	if file := strings.TrimSpace(data.CACertFile.ValueString()); file != "" {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			diags.AddAttributeError(path.Root("ca_cert_file"), "Failed to read CA certificate file", err.Error())
+			return nil
+		}
+		opts.CACertPEM = append(append(opts.CACertPEM, '\n'), pem...)
+	}
+
+	if data.ClientCertPEM.IsNull() != data.ClientKeyPEM.IsNull() {
+		diags.AddAttributeError(path.Root("client_cert_pem"), "Incomplete client certificate", "`client_cert_pem` and `client_key_pem` must be set together.")
+		return nil
+	}
+
+	if opts.InsecureSkipVerify {
+		diags.AddAttributeWarning(
+			path.Root("insecure_skip_verify"),
+			"TLS certificate verification is disabled",
+			"`insecure_skip_verify = true` accepts any certificate presented by the Penguin endpoint. "+
+				"Anyone able to intercept the connection can read the auth token, JWT and root passwords. Do not use this outside of local testing.",
+		)
+	}
+	return opts
+}
+
 func (p *PenguinProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewTencentCloudVirtualMachineResource,
 		NewTencentCloudElasticIPResource,
 		NewTencentCloudBandwidthPackageSelectionResource,
+		NewTencentCloudVirtualMachinePasswordResource,
+		NewTencentCloudVirtualMachineRenewalResource,
+		NewTencentCloudVirtualMachineTransferResetResource,
 	}
 }
 
@@ -4,10 +4,13 @@
 package provider
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
 // testAccProtoV6ProviderFactories is used to instantiate a provider during acceptance testing.
@@ -27,3 +30,53 @@ func TestAccFactoriesCompile(t *testing.T) {
 	testAccPreCheck(t)
 	_ = testAccProtoV6ProviderFactories
 }
+
+func TestProviderConfigure_JWTClaimsWithRegions(t *testing.T) {
+	protocol, schemas := testConfiguredProviderServer(t, "http://penguin.invalid")
+	providerType, ok := schemas.Provider.ValueType().(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected provider type %T", schemas.Provider.ValueType())
+	}
+	claimsType, ok := providerType.AttributeTypes["jwt_claims"].(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected jwt_claims type %T", providerType.AttributeTypes["jwt_claims"])
+	}
+	regionsType, ok := providerType.AttributeTypes["endpoint_by_region"].(tftypes.Map)
+	if !ok {
+		t.Fatalf("unexpected endpoint_by_region type %T", providerType.AttributeTypes["endpoint_by_region"])
+	}
+	regionType, ok := regionsType.ElementType.(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected endpoint_by_region element type %T", regionsType.ElementType)
+	}
+
+	// withNulls builds an object of typ whose attributes are null unless set in values.
+	withNulls := func(typ tftypes.Object, values map[string]tftypes.Value) tftypes.Value {
+		attrs := make(map[string]tftypes.Value, len(typ.AttributeTypes))
+		for name, attrType := range typ.AttributeTypes {
+			attrs[name] = tftypes.NewValue(attrType, nil)
+			if v, ok := values[name]; ok {
+				attrs[name] = v
+			}
+		}
+		return tftypes.NewValue(typ, attrs)
+	}
+
+	config := testDynamicValue(t, providerType, map[string]tftypes.Value{
+		"endpoint":   tftypes.NewValue(tftypes.String, "http://penguin.invalid"),
+		"auth_token": tftypes.NewValue(tftypes.String, "token"),
+		"jwt_claims": withNulls(claimsType, nil),
+		"endpoint_by_region": tftypes.NewValue(regionsType, map[string]tftypes.Value{
+			"ap-shanghai": withNulls(regionType, map[string]tftypes.Value{
+				"endpoint": tftypes.NewValue(tftypes.String, "http://ap-shanghai.invalid"),
+			}),
+		}),
+	})
+	resp, err := protocol.ConfigureProvider(context.Background(), &tfprotov6.ConfigureProviderRequest{Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Diagnostics) != 1 || !strings.Contains(resp.Diagnostics[0].Detail, "`endpoint_by_region`") {
+		t.Fatalf("expected jwt_claims to be rejected with endpoint_by_region, got %v", resp.Diagnostics)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// setPartialState stores val after a failed apply step. Values still unknown from the plan are
+// stored as null, since Terraform rejects unknown values in state; the next refresh fills them.
+func setPartialState(ctx context.Context, state *tfsdk.State, val any) diag.Diagnostics {
+	diags := state.Set(ctx, val)
+	if diags.HasError() {
+		return diags
+	}
+
+	raw, err := tftypes.Transform(state.Raw, func(_ *tftypes.AttributePath, v tftypes.Value) (tftypes.Value, error) {
+		if !v.IsKnown() {
+			return tftypes.NewValue(v.Type(), nil), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		diags.AddError("Failed to save partial state", err.Error())
+		return diags
+	}
+	state.Raw = raw
+	return diags
+}
+
+// privateStateReader is satisfied by the framework's private state data, whose concrete type is internal.
+type privateStateReader interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// privateStateWriter is the writable counterpart of privateStateReader.
+type privateStateWriter interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
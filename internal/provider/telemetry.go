@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/indexyz/terraform-provider-penguin/internal/provider"
+
+	telemetryFlushTimeout = 5 * time.Second
+)
+
+var (
+	telemetryOnce     sync.Once
+	telemetryProvider *sdktrace.TracerProvider
+)
+
+// setupTelemetry installs an OTLP/HTTP trace exporter configured from the standard OTEL_*
+// environment variables, once per process. Tracing stays disabled unless an OTLP endpoint is
+// configured or OTEL_TRACES_EXPORTER=otlp; OTEL_SDK_DISABLED=true and OTEL_TRACES_EXPORTER=none
+// turn it off.
+func setupTelemetry(ctx context.Context, version string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	telemetryOnce.Do(func() {
+		if !tracingEnabled() {
+			return
+		}
+
+		if protocol := telemetryEnv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != "http/protobuf" {
+			diags.AddWarning(
+				"Unsupported OpenTelemetry protocol",
+				fmt.Sprintf("The provider only exports traces via OTLP over HTTP (`http/protobuf`); %q is ignored.", protocol),
+			)
+		}
+
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			diags.AddWarning("Failed to configure OpenTelemetry exporter", "Tracing is disabled: "+err.Error())
+			return
+		}
+
+		res, err := sdkresource.New(ctx,
+			sdkresource.WithAttributes(
+				attribute.String("service.name", "terraform-provider-penguin"),
+				attribute.String("service.version", version),
+			),
+			sdkresource.WithFromEnv(),
+		)
+		if err != nil {
+			diags.AddWarning("Failed to build OpenTelemetry resource", err.Error())
+		}
+
+		telemetryProvider = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+		)
+		otel.SetTracerProvider(telemetryProvider)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	})
+	return diags
+}
+
+func tracingEnabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	switch strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")) {
+	case "none":
+		return false
+	case "otlp":
+		return true
+	}
+	return telemetryEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT") != ""
+}
+
+func telemetryEnv(keys ...string) string {
+	for _, key := range keys {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// traceResourceOperation starts a span for a resource operation, replaces *ctx with the span
+// context and returns the function that ends it, recording the error diagnostics:
+//
+//	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine", "Create", &resp.Diagnostics)()
+//
+// Without a parent span in ctx, a `TRACEPARENT` environment variable set by the calling pipeline
+// becomes the parent. Spans are exported in batches and flushed by ShutdownTelemetry.
+func traceResourceOperation(ctx *context.Context, resourceType string, operation string, diags *diag.Diagnostics) func() {
+	parent := *ctx
+	if !trace.SpanContextFromContext(parent).IsValid() {
+		parent = propagation.TraceContext{}.Extract(parent, propagation.MapCarrier{
+			"traceparent": os.Getenv("TRACEPARENT"),
+			"tracestate":  os.Getenv("TRACESTATE"),
+		})
+	}
+	spanCtx, span := otel.Tracer(tracerName).Start(parent, resourceType+"."+operation,
+		trace.WithAttributes(
+			attribute.String("terraform.resource_type", resourceType),
+			attribute.String("terraform.operation", operation),
+		),
+	)
+	*ctx = spanCtx
+
+	return func() {
+		if diags.HasError() {
+			var summaries []string
+			for _, d := range diags.Errors() {
+				summaries = append(summaries, d.Summary())
+			}
+			span.SetStatus(codes.Error, strings.Join(summaries, "; "))
+		}
+		span.End()
+	}
+}
+
+// ShutdownTelemetry exports the spans still queued in the batch processor and stops the exporter.
+// Call it once after the provider server stops; it does nothing when tracing is disabled.
+func ShutdownTelemetry(ctx context.Context) error {
+	if telemetryProvider == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, telemetryFlushTimeout)
+	defer cancel()
+	return telemetryProvider.Shutdown(ctx)
+}
+
+// setSpanVirtualMachineID tags the current span with the Penguin VM UUID.
+func setSpanVirtualMachineID(ctx context.Context, id string) {
+	if id != "" {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("penguin.vm_id", id))
+	}
+}
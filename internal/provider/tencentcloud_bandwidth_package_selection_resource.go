@@ -7,8 +7,10 @@ import (
 	"context"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
@@ -35,6 +37,8 @@ type TencentCloudBandwidthPackageSelectionResourceModel struct {
 	NetworkType        types.String `tfsdk:"network_type"`
 	BandwidthPackageID types.String `tfsdk:"bandwidth_package_id"`
 	AvailableCount     types.Int64  `tfsdk:"available_count"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 func (r *TencentCloudBandwidthPackageSelectionResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -60,12 +64,19 @@ func (r *TencentCloudBandwidthPackageSelectionResource) Schema(ctx context.Conte
 				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
 			},
 			"bandwidth_package_id": schema.StringAttribute{
-				Computed: true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
 			},
 			"available_count": schema.Int64Attribute{
-				Computed: true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+			}),
+		},
 	}
 }
 
@@ -74,6 +85,8 @@ func (r *TencentCloudBandwidthPackageSelectionResource) Configure(ctx context.Co
 }
 
 func (r *TencentCloudBandwidthPackageSelectionResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_bandwidth_package_selection", "Create", &resp.Diagnostics)()
+
 	if r.client == nil {
 		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
 		return
@@ -85,6 +98,14 @@ func (r *TencentCloudBandwidthPackageSelectionResource) Create(ctx context.Conte
 		return
 	}
 
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultAPITimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
 	if plan.Region.IsUnknown() || plan.NetworkType.IsUnknown() {
 		resp.Diagnostics.AddError("Unknown configuration", "`region` and `network_type` must be known during planning.")
 		return
@@ -119,6 +140,8 @@ func (r *TencentCloudBandwidthPackageSelectionResource) Create(ctx context.Conte
 }
 
 func (r *TencentCloudBandwidthPackageSelectionResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_bandwidth_package_selection", "Read", &resp.Diagnostics)()
+
 	var state TencentCloudBandwidthPackageSelectionResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -130,6 +153,8 @@ func (r *TencentCloudBandwidthPackageSelectionResource) Read(ctx context.Context
 }
 
 func (r *TencentCloudBandwidthPackageSelectionResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_bandwidth_package_selection", "Update", &resp.Diagnostics)()
+
 	var plan TencentCloudBandwidthPackageSelectionResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
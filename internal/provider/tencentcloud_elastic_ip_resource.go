@@ -5,8 +5,10 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -37,6 +39,8 @@ type TencentCloudElasticIPResourceModel struct {
 	AddressName              types.String `tfsdk:"address_name"`
 	SharedBandwidthPackageID types.String `tfsdk:"shared_bandwidth_package_id"`
 	Address                  types.String `tfsdk:"address"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 func (r *TencentCloudElasticIPResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -78,9 +82,16 @@ func (r *TencentCloudElasticIPResource) Schema(ctx context.Context, req resource
 				},
 			},
 			"address": schema.StringAttribute{
-				Computed: true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -89,6 +100,8 @@ func (r *TencentCloudElasticIPResource) Configure(ctx context.Context, req resou
 }
 
 func (r *TencentCloudElasticIPResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_elastic_ip", "Create", &resp.Diagnostics)()
+
 	if r.client == nil {
 		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
 		return
@@ -100,6 +113,14 @@ func (r *TencentCloudElasticIPResource) Create(ctx context.Context, req resource
 		return
 	}
 
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultAPITimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
 	if plan.Region.IsUnknown() || plan.BandwidthLimitMbps.IsUnknown() || plan.AddressName.IsUnknown() || plan.SharedBandwidthPackageID.IsUnknown() {
 		resp.Diagnostics.AddError(
 			"Unknown elastic IP configuration",
@@ -132,6 +153,8 @@ func (r *TencentCloudElasticIPResource) Create(ctx context.Context, req resource
 }
 
 func (r *TencentCloudElasticIPResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_elastic_ip", "Read", &resp.Diagnostics)()
+
 	var state TencentCloudElasticIPResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -142,6 +165,8 @@ func (r *TencentCloudElasticIPResource) Read(ctx context.Context, req resource.R
 }
 
 func (r *TencentCloudElasticIPResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_elastic_ip", "Update", &resp.Diagnostics)()
+
 	var plan TencentCloudElasticIPResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	if resp.Diagnostics.HasError() {
@@ -152,6 +177,8 @@ func (r *TencentCloudElasticIPResource) Update(ctx context.Context, req resource
 }
 
 func (r *TencentCloudElasticIPResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_elastic_ip", "Delete", &resp.Diagnostics)()
+
 	if r.client == nil {
 		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
 		return
@@ -163,13 +190,21 @@ func (r *TencentCloudElasticIPResource) Delete(ctx context.Context, req resource
 		return
 	}
 
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultAPITimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
 	if state.Region.IsUnknown() || state.ID.IsUnknown() {
 		resp.Diagnostics.AddError("Unknown state", "Cannot delete elastic IP with unknown state.")
 		return
 	}
 
 	if err := r.client.DeleteElasticIP(ctx, state.Region.ValueString(), state.ID.ValueString()); err != nil {
-		if isNotFound(err) {
+		if errors.Is(err, penguin.ErrNotFound) {
 			return
 		}
 		resp.Diagnostics.AddError("Failed to delete elastic IP", err.Error())
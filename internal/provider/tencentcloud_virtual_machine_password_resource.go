@@ -0,0 +1,239 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
+)
+
+var (
+	_ resource.Resource               = &TencentCloudVirtualMachinePasswordResource{}
+	_ resource.ResourceWithModifyPlan = &TencentCloudVirtualMachinePasswordResource{}
+)
+
+func NewTencentCloudVirtualMachinePasswordResource() resource.Resource {
+	return &TencentCloudVirtualMachinePasswordResource{}
+}
+
+// TencentCloudVirtualMachinePasswordResource rotates the root password of a VM via
+// `POST /tencentcloud/vms/:id/reset-password`. Every (re)creation of the resource is one reset;
+// changing `keepers` or exceeding `rotation_days` plans a replacement and therefore a new password.
+type TencentCloudVirtualMachinePasswordResource struct {
+	client *penguin.Client
+}
+
+type TencentCloudVirtualMachinePasswordResourceModel struct {
+	ID               types.String `tfsdk:"id"`
+	VirtualMachineID types.String `tfsdk:"virtual_machine_id"`
+	Keepers          types.Map    `tfsdk:"keepers"`
+	RotationDays     types.Int64  `tfsdk:"rotation_days"`
+	ForceStop        types.Bool   `tfsdk:"force_stop"`
+
+	Password  types.String `tfsdk:"password"`
+	RotatedAt types.String `tfsdk:"rotated_at"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+func (r *TencentCloudVirtualMachinePasswordResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tencentcloud_virtual_machine_password"
+}
+
+func (r *TencentCloudVirtualMachinePasswordResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reset the root password of a virtual machine via `POST /tencentcloud/vms/:id/reset-password`. The password is reset on create and again whenever `keepers` change or the `rotation_days` window has elapsed. Destroying this resource does not change the password.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"virtual_machine_id": schema.StringAttribute{
+				MarkdownDescription: "Penguin UUID of the virtual machine.",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"keepers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary map of values that, when changed, reset the password.",
+				Optional:            true,
+				ElementType:         types.StringType,
+				PlanModifiers:       []planmodifier.Map{mapplanmodifier.RequiresReplace()},
+			},
+			"rotation_days": schema.Int64Attribute{
+				MarkdownDescription: "Number of days after which the next plan resets the password again.",
+				Optional:            true,
+			},
+			"force_stop": schema.BoolAttribute{
+				MarkdownDescription: "Allow the service to force a shutdown when the instance is running.",
+				Optional:            true,
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: "Password returned by the most recent reset.",
+				Computed:            true,
+				Sensitive:           true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"rotated_at": schema.StringAttribute{
+				MarkdownDescription: "RFC 3339 timestamp of the most recent reset.",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+			}),
+		},
+	}
+}
+
+func (r *TencentCloudVirtualMachinePasswordResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	configureResourceClient(req, resp, &r.client)
+}
+
+func (r *TencentCloudVirtualMachinePasswordResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to rotate on create or destroy.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan TencentCloudVirtualMachinePasswordResourceModel
+	var state TencentCloudVirtualMachinePasswordResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.RotationDays.IsNull() || plan.RotationDays.IsUnknown() {
+		return
+	}
+
+	rotatedAt, err := time.Parse(time.RFC3339, state.RotatedAt.ValueString())
+	if err != nil {
+		return
+	}
+	if time.Now().Before(rotatedAt.Add(time.Duration(plan.RotationDays.ValueInt64()) * 24 * time.Hour)) {
+		return
+	}
+
+	plan.Password = types.StringUnknown()
+	plan.RotatedAt = types.StringUnknown()
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+	resp.RequiresReplace = append(resp.RequiresReplace, path.Root("rotated_at"))
+}
+
+func (r *TencentCloudVirtualMachinePasswordResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine_password", "Create", &resp.Diagnostics)()
+
+	if r.client == nil {
+		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
+		return
+	}
+
+	var plan TencentCloudVirtualMachinePasswordResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultAPITimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	if plan.VirtualMachineID.IsUnknown() || plan.ForceStop.IsUnknown() {
+		resp.Diagnostics.AddError(
+			"Unknown password reset configuration",
+			"`virtual_machine_id` and `force_stop` must be known during planning to reset the password.",
+		)
+		return
+	}
+
+	request := penguin.ResetVirtualMachinePasswordRequest{}
+	if !plan.ForceStop.IsNull() {
+		v := plan.ForceStop.ValueBool()
+		request.ForceStop = &v
+	}
+
+	out, err := r.client.ResetVirtualMachinePassword(ctx, plan.VirtualMachineID.ValueString(), request)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to reset virtual machine password", err.Error())
+		return
+	}
+
+	state := plan
+	state.ID = plan.VirtualMachineID
+	state.Password = types.StringValue(out.Password)
+	state.RotatedAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *TencentCloudVirtualMachinePasswordResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine_password", "Read", &resp.Diagnostics)()
+
+	if r.client == nil {
+		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
+		return
+	}
+
+	var state TencentCloudVirtualMachinePasswordResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultAPITimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	// The password itself is a snapshot of the last reset; only drop state when the VM is gone.
+	if _, err := r.client.GetVirtualMachineStatus(ctx, state.VirtualMachineID.ValueString()); err != nil {
+		if errors.Is(err, penguin.ErrNotFound) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Failed to read virtual machine status", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *TencentCloudVirtualMachinePasswordResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine_password", "Update", &resp.Diagnostics)()
+
+	var plan TencentCloudVirtualMachinePasswordResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only rotation_days and force_stop can change in place; they take effect on the next reset.
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *TencentCloudVirtualMachinePasswordResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Nothing to undo remotely; the last password stays in effect.
+}
@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestTencentCloudVirtualMachinePasswordResource_Create(t *testing.T) {
+	var mu sync.Mutex
+	var resetBodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/tencentcloud/vms/vm-1/reset-password" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		resetBodies = append(resetBodies, string(body))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"password":"s3cret"}`))
+	}))
+	defer server.Close()
+
+	const typeName = "penguin_tencentcloud_virtual_machine_password"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, server.URL)
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	config := testDynamicValue(t, stateType, map[string]tftypes.Value{
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"force_stop":         tftypes.NewValue(tftypes.Bool, true),
+	})
+	planned := testDynamicValue(t, stateType, map[string]tftypes.Value{
+		"id":                 tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"force_stop":         tftypes.NewValue(tftypes.Bool, true),
+		"password":           tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		"rotated_at":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+	})
+	noState, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := protocol.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   &noState,
+		PlannedState: planned,
+		Config:       config,
+	})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("ApplyResourceChange: %v %v", err, resp.Diagnostics)
+	}
+
+	mu.Lock()
+	bodies := resetBodies
+	mu.Unlock()
+	if len(bodies) != 1 || bodies[0] != `{"forceStop":true}` {
+		t.Fatalf("expected one reset with forceStop, got %q", bodies)
+	}
+
+	state, err := resp.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attrs map[string]tftypes.Value
+	if err := state.As(&attrs); err != nil {
+		t.Fatal(err)
+	}
+	if !attrs["id"].Equal(tftypes.NewValue(tftypes.String, "vm-1")) ||
+		!attrs["password"].Equal(tftypes.NewValue(tftypes.String, "s3cret")) {
+		t.Fatalf("unexpected state after reset: id %s, password %s", attrs["id"], attrs["password"])
+	}
+	var rotatedAt string
+	if err := attrs["rotated_at"].As(&rotatedAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := time.Parse(time.RFC3339, rotatedAt); err != nil {
+		t.Fatalf("expected an RFC 3339 rotated_at, got %q", rotatedAt)
+	}
+}
+
+func TestTencentCloudVirtualMachinePasswordResource_PlanRotation(t *testing.T) {
+	const typeName = "penguin_tencentcloud_virtual_machine_password"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, "http://penguin.invalid")
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	config := testDynamicValue(t, stateType, map[string]tftypes.Value{
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"rotation_days":      tftypes.NewValue(tftypes.Number, 7),
+	})
+
+	plan := func(rotatedAt time.Time) *tfprotov6.PlanResourceChangeResponse {
+		t.Helper()
+		state := testDynamicValue(t, stateType, map[string]tftypes.Value{
+			"id":                 tftypes.NewValue(tftypes.String, "vm-1"),
+			"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+			"rotation_days":      tftypes.NewValue(tftypes.Number, 7),
+			"password":           tftypes.NewValue(tftypes.String, "s3cret"),
+			"rotated_at":         tftypes.NewValue(tftypes.String, rotatedAt.UTC().Format(time.RFC3339)),
+		})
+		resp, err := protocol.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+			TypeName:         typeName,
+			PriorState:       state,
+			ProposedNewState: state,
+			Config:           config,
+		})
+		if err != nil || len(resp.Diagnostics) > 0 {
+			t.Fatalf("PlanResourceChange: %v %v", err, resp.Diagnostics)
+		}
+		return resp
+	}
+	plannedPassword := func(resp *tfprotov6.PlanResourceChangeResponse) tftypes.Value {
+		t.Helper()
+		planned, err := resp.PlannedState.Unmarshal(stateType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var attrs map[string]tftypes.Value
+		if err := planned.As(&attrs); err != nil {
+			t.Fatal(err)
+		}
+		return attrs["password"]
+	}
+
+	resp := plan(time.Now().Add(-24 * time.Hour))
+	if len(resp.RequiresReplace) > 0 || !plannedPassword(resp).IsKnown() {
+		t.Fatalf("expected no rotation inside the window, got replace %v", resp.RequiresReplace)
+	}
+
+	resp = plan(time.Now().Add(-8 * 24 * time.Hour))
+	if len(resp.RequiresReplace) != 1 || !resp.RequiresReplace[0].Equal(tftypes.NewAttributePath().WithAttributeName("rotated_at")) {
+		t.Fatalf("expected a replacement through rotated_at once the window elapsed, got %v", resp.RequiresReplace)
+	}
+	if plannedPassword(resp).IsKnown() {
+		t.Fatal("expected the password to be unknown in a rotation plan")
+	}
+}
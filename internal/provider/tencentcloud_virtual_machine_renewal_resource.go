@@ -0,0 +1,333 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
+)
+
+var (
+	_ resource.Resource               = &TencentCloudVirtualMachineRenewalResource{}
+	_ resource.ResourceWithModifyPlan = &TencentCloudVirtualMachineRenewalResource{}
+)
+
+// renewalRejectedKey holds, in private state, the `expired_at` at which the service last rejected
+// a renewal with 409. The plan does not schedule another attempt until the expiry changes.
+const renewalRejectedKey = "rejected_expired_at"
+
+func NewTencentCloudVirtualMachineRenewalResource() resource.Resource {
+	return &TencentCloudVirtualMachineRenewalResource{}
+}
+
+// TencentCloudVirtualMachineRenewalResource keeps a prepaid VM from expiring. Refresh tracks
+// `expired_at`; once the expiry falls within `renew_before_days`, the plan schedules an update
+// which calls `POST /tencentcloud/vms/:id/renew`.
+type TencentCloudVirtualMachineRenewalResource struct {
+	client *penguin.Client
+}
+
+type TencentCloudVirtualMachineRenewalResourceModel struct {
+	ID               types.String `tfsdk:"id"`
+	VirtualMachineID types.String `tfsdk:"virtual_machine_id"`
+	PeriodMonths     types.Int64  `tfsdk:"period_months"`
+	RenewBeforeDays  types.Int64  `tfsdk:"renew_before_days"`
+
+	ExpiredAt types.String `tfsdk:"expired_at"`
+	RenewedAt types.String `tfsdk:"renewed_at"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+func (r *TencentCloudVirtualMachineRenewalResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tencentcloud_virtual_machine_renewal"
+}
+
+func (r *TencentCloudVirtualMachineRenewalResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Renew a prepaid virtual machine via `POST /tencentcloud/vms/:id/renew` whenever its `expired_at` is within `renew_before_days`. Each apply re-evaluates the window; after the service rejects a renewal with `409 Conflict`, none is planned again until `expired_at` changes. Destroying this resource does not cancel any renewal.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"virtual_machine_id": schema.StringAttribute{
+				MarkdownDescription: "Penguin UUID of the prepaid virtual machine.",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"period_months": schema.Int64Attribute{
+				MarkdownDescription: "Months to extend the prepaid term by on each renewal. The service defaults to `1` when omitted.",
+				Optional:            true,
+			},
+			"renew_before_days": schema.Int64Attribute{
+				MarkdownDescription: "Renew once the instance expires within this many days.",
+				Required:            true,
+			},
+			"expired_at": schema.StringAttribute{
+				MarkdownDescription: "Prepaid expiration as last reported by the service.",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"renewed_at": schema.StringAttribute{
+				MarkdownDescription: "RFC 3339 timestamp of the last renewal performed by this resource.",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+			}),
+		},
+	}
+}
+
+func (r *TencentCloudVirtualMachineRenewalResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	configureResourceClient(req, resp, &r.client)
+}
+
+func (r *TencentCloudVirtualMachineRenewalResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Create always evaluates the window; nothing to do on destroy.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan TencentCloudVirtualMachineRenewalResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.RenewBeforeDays.IsUnknown() || !renewalDue(plan.ExpiredAt.ValueString(), plan.RenewBeforeDays.ValueInt64()) {
+		return
+	}
+	rejected, diags := req.Private.GetKey(ctx, renewalRejectedKey)
+	resp.Diagnostics.Append(diags...)
+	var rejectedExpiry string
+	if len(rejected) > 0 && json.Unmarshal(rejected, &rejectedExpiry) == nil && rejectedExpiry == plan.ExpiredAt.ValueString() {
+		return
+	}
+
+	plan.ExpiredAt = types.StringUnknown()
+	plan.RenewedAt = types.StringUnknown()
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+func (r *TencentCloudVirtualMachineRenewalResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine_renewal", "Create", &resp.Diagnostics)()
+
+	if r.client == nil {
+		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
+		return
+	}
+
+	var plan TencentCloudVirtualMachineRenewalResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultAPITimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	state := plan
+	state.ID = plan.VirtualMachineID
+	state.RenewedAt = types.StringNull()
+	rejected, diags := r.renewIfDue(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	resp.Diagnostics.Append(setRenewalRejected(ctx, resp.Private, rejected, state.ExpiredAt)...)
+}
+
+func (r *TencentCloudVirtualMachineRenewalResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine_renewal", "Read", &resp.Diagnostics)()
+
+	if r.client == nil {
+		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
+		return
+	}
+
+	var state TencentCloudVirtualMachineRenewalResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultAPITimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	status, err := r.client.GetVirtualMachineStatus(ctx, state.VirtualMachineID.ValueString())
+	if err != nil {
+		if errors.Is(err, penguin.ErrNotFound) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Failed to read virtual machine status", err.Error())
+		return
+	}
+
+	state.ExpiredAt = types.StringPointerValue(status.ExpiredAt)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *TencentCloudVirtualMachineRenewalResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine_renewal", "Update", &resp.Diagnostics)()
+
+	if r.client == nil {
+		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
+		return
+	}
+
+	var plan TencentCloudVirtualMachineRenewalResourceModel
+	var state TencentCloudVirtualMachineRenewalResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, defaultAPITimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	newState := plan
+	newState.ID = state.ID
+	newState.RenewedAt = state.RenewedAt
+	// ModifyPlan marks expired_at unknown only when a renewal is due; any other change is
+	// configuration only and must not buy another term.
+	if !plan.ExpiredAt.IsUnknown() {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &newState)...)
+		return
+	}
+
+	rejected, diags := r.renewIfDue(ctx, &newState)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &newState)...)
+	resp.Diagnostics.Append(setRenewalRejected(ctx, resp.Private, rejected, newState.ExpiredAt)...)
+}
+
+func (r *TencentCloudVirtualMachineRenewalResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Renewals cannot be undone; removing the resource only stops future renewals.
+}
+
+// renewIfDue refreshes expired_at from the status endpoint and renews the instance when it is
+// inside the configured window. A 409 from the service is reported as a warning and rejected is
+// set.
+func (r *TencentCloudVirtualMachineRenewalResource) renewIfDue(ctx context.Context, state *TencentCloudVirtualMachineRenewalResourceModel) (rejected bool, diags diag.Diagnostics) {
+	if state.VirtualMachineID.IsUnknown() || state.PeriodMonths.IsUnknown() || state.RenewBeforeDays.IsUnknown() {
+		diags.AddError(
+			"Unknown renewal configuration",
+			"All input values must be known during planning to renew a virtual machine.",
+		)
+		return false, diags
+	}
+
+	id := state.VirtualMachineID.ValueString()
+	status, err := r.client.GetVirtualMachineStatus(ctx, id)
+	if err != nil {
+		diags.AddError("Failed to read virtual machine status", err.Error())
+		return false, diags
+	}
+	state.ExpiredAt = types.StringPointerValue(status.ExpiredAt)
+
+	if status.ExpiredAt == nil {
+		diags.AddWarning(
+			"Virtual machine has no prepaid expiration",
+			fmt.Sprintf("The status endpoint reports no `expiredAt` for %s; it is likely not prepaid, so no renewal was attempted.", id),
+		)
+		return false, diags
+	}
+	if !renewalDue(*status.ExpiredAt, state.RenewBeforeDays.ValueInt64()) {
+		return false, diags
+	}
+
+	request := penguin.RenewVirtualMachineRequest{}
+	if !state.PeriodMonths.IsNull() {
+		v := state.PeriodMonths.ValueInt64()
+		request.PeriodMonths = &v
+	}
+
+	out, err := r.client.RenewVirtualMachine(ctx, id, request)
+	if err != nil {
+		if errors.Is(err, penguin.ErrConflict) {
+			diags.AddWarning(
+				"Virtual machine renewal rejected",
+				fmt.Sprintf("The Penguin service rejected renewing %s, e.g. because it is not prepaid or the expiration did not change: %s\n\n"+
+					"No further renewal is planned until `expired_at` changes.", id, err),
+			)
+			return true, diags
+		}
+		diags.AddError("Failed to renew virtual machine", err.Error())
+		return false, diags
+	}
+
+	if out.ExpiredAt != nil {
+		state.ExpiredAt = types.StringValue(*out.ExpiredAt)
+	}
+	state.RenewedAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+
+	return false, diags
+}
+
+// setRenewalRejected records the expiry a renewal was rejected at, or clears it.
+func setRenewalRejected(ctx context.Context, private privateStateWriter, rejected bool, expiredAt types.String) diag.Diagnostics {
+	if !rejected {
+		return private.SetKey(ctx, renewalRejectedKey, nil)
+	}
+	value, err := json.Marshal(expiredAt.ValueString())
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Failed to record rejected renewal", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, renewalRejectedKey, value)
+}
+
+// renewalDue reports whether expiredAt (RFC 3339) falls within beforeDays from now. Unparseable
+// or empty values are never due, so postpaid instances do not produce perpetual diffs.
+func renewalDue(expiredAt string, beforeDays int64) bool {
+	expiry, err := time.Parse(time.RFC3339, expiredAt)
+	if err != nil {
+		return false
+	}
+	return !time.Now().Add(time.Duration(beforeDays) * 24 * time.Hour).Before(expiry)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestTencentCloudVirtualMachineRenewalResource_PlanAfterRejection(t *testing.T) {
+	const typeName = "penguin_tencentcloud_virtual_machine_renewal"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, "http://penguin.invalid")
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	expiredAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	config := testDynamicValue(t, stateType, map[string]tftypes.Value{
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"renew_before_days":  tftypes.NewValue(tftypes.Number, 7),
+	})
+	state := testDynamicValue(t, stateType, map[string]tftypes.Value{
+		"id":                 tftypes.NewValue(tftypes.String, "vm-1"),
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"renew_before_days":  tftypes.NewValue(tftypes.Number, 7),
+		"expired_at":         tftypes.NewValue(tftypes.String, expiredAt),
+	})
+
+	plannedExpiry := func(private []byte) tftypes.Value {
+		t.Helper()
+		resp, err := protocol.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+			TypeName:         typeName,
+			PriorState:       state,
+			ProposedNewState: state,
+			Config:           config,
+			PriorPrivate:     private,
+		})
+		if err != nil || len(resp.Diagnostics) > 0 {
+			t.Fatalf("PlanResourceChange: %v %v", err, resp.Diagnostics)
+		}
+		planned, err := resp.PlannedState.Unmarshal(stateType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var attrs map[string]tftypes.Value
+		if err := planned.As(&attrs); err != nil {
+			t.Fatal(err)
+		}
+		return attrs["expired_at"]
+	}
+
+	if plannedExpiry(nil).IsKnown() {
+		t.Fatal("expected a renewal to be planned inside the window")
+	}
+
+	rejectedAt, _ := json.Marshal(expiredAt)
+	private, _ := json.Marshal(map[string][]byte{renewalRejectedKey: rejectedAt})
+	if got := plannedExpiry(private); !got.Equal(tftypes.NewValue(tftypes.String, expiredAt)) {
+		t.Fatalf("expected no renewal after a rejection at the same expiry, got expired_at %s", got)
+	}
+
+	rejectedAt, _ = json.Marshal("2000-01-01T00:00:00Z")
+	private, _ = json.Marshal(map[string][]byte{renewalRejectedKey: rejectedAt})
+	if plannedExpiry(private).IsKnown() {
+		t.Fatal("expected a renewal once the expiry changed since the rejection")
+	}
+}
+
+func TestTencentCloudVirtualMachineRenewalResource_UpdateWithoutRenewal(t *testing.T) {
+	const typeName = "penguin_tencentcloud_virtual_machine_renewal"
+	ctx := context.Background()
+	// Any request would fail against this endpoint.
+	protocol, schemas := testConfiguredProviderServer(t, "http://penguin.invalid")
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	expiredAt := time.Now().Add(90 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	values := func(periodMonths int) map[string]tftypes.Value {
+		return map[string]tftypes.Value{
+			"id":                 tftypes.NewValue(tftypes.String, "vm-1"),
+			"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+			"period_months":      tftypes.NewValue(tftypes.Number, periodMonths),
+			"renew_before_days":  tftypes.NewValue(tftypes.Number, 7),
+			"expired_at":         tftypes.NewValue(tftypes.String, expiredAt),
+		}
+	}
+
+	planned := testDynamicValue(t, stateType, values(3))
+	resp, err := protocol.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   testDynamicValue(t, stateType, values(1)),
+		PlannedState: planned,
+		Config:       planned,
+	})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("ApplyResourceChange: %v %v", err, resp.Diagnostics)
+	}
+
+	state, err := resp.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attrs map[string]tftypes.Value
+	if err := state.As(&attrs); err != nil {
+		t.Fatal(err)
+	}
+	if !attrs["period_months"].Equal(tftypes.NewValue(tftypes.Number, 3)) ||
+		!attrs["expired_at"].Equal(tftypes.NewValue(tftypes.String, expiredAt)) {
+		t.Fatalf("unexpected state: period_months %s, expired_at %s", attrs["period_months"], attrs["expired_at"])
+	}
+}
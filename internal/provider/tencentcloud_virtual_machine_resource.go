@@ -5,20 +5,59 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
 )
 
 var (
-	_ resource.Resource = &TencentCloudVirtualMachineResource{}
+	_ resource.Resource                   = &TencentCloudVirtualMachineResource{}
+	_ resource.ResourceWithImportState    = &TencentCloudVirtualMachineResource{}
+	_ resource.ResourceWithModifyPlan     = &TencentCloudVirtualMachineResource{}
+	_ resource.ResourceWithUpgradeState   = &TencentCloudVirtualMachineResource{}
+	_ resource.ResourceWithValidateConfig = &TencentCloudVirtualMachineResource{}
+)
+
+// virtualMachineImportedKey marks private state of imported VMs whose inputs could not be
+// recovered from the status endpoint, so filling them in config does not force replacement.
+const virtualMachineImportedKey = "imported"
+
+// virtualMachineImportAttributes are the required inputs the status endpoint does not report.
+// The import identifier may carry them after the UUID, e.g. `<uuid>,name=web-1,vpc_id=vpc-1`.
+var virtualMachineImportAttributes = []string{"name", "security_group", "vpc_id", "subnet_id"}
+
+const (
+	defaultVirtualMachineCreateTimeout = 30 * time.Minute
+	defaultVirtualMachineReadTimeout   = 5 * time.Minute
+	defaultVirtualMachineUpdateTimeout = 30 * time.Minute
+	defaultVirtualMachineDeleteTimeout = 20 * time.Minute
+)
+
+const (
+	powerStateRunning = "running"
+	powerStateStopped = "stopped"
+
+	rebuildStrategyReplace   = "replace"
+	rebuildStrategyReinstall = "reinstall"
+
+	chargeTypePostpaidByHour = "POSTPAID_BY_HOUR"
 )
 
 func NewTencentCloudVirtualMachineResource() resource.Resource {
@@ -32,28 +71,31 @@ type TencentCloudVirtualMachineResource struct {
 type TencentCloudVirtualMachineResourceModel struct {
 	ID types.String `tfsdk:"id"`
 
-	Name           types.String `tfsdk:"name"`
-	Zone           types.String `tfsdk:"zone"`
-	InstanceType   types.String `tfsdk:"instance_type"`
-	SecurityGroup  types.String `tfsdk:"security_group"`
-	SystemImage    types.String `tfsdk:"system_image"`
-	VPCID          types.String `tfsdk:"vpc_id"`
-	SubnetID       types.String `tfsdk:"subnet_id"`
-	PrivateIP      types.String `tfsdk:"private_ip_address"`
-	SystemDiskGiB  types.Int64  `tfsdk:"system_disk_size_gib"`
-	SharedBWPKGID  types.String `tfsdk:"shared_bandwidth_package_id"`
-	ElasticIPID    types.String `tfsdk:"elastic_ip_id"`
-	BandwidthLimit types.Int64  `tfsdk:"bandwidth_limit_mbps"`
-	ChargeType     types.String `tfsdk:"charge_type"`
-	RootPassword   types.String `tfsdk:"root_login_password"`
-	TotalTransfer  types.Int64  `tfsdk:"total_transfer_kb"`
-	ProjectID      types.Int64  `tfsdk:"project_id"`
-	PeriodMonths   types.Int64  `tfsdk:"period_months"`
-	CloudInitData  types.String `tfsdk:"cloud_init_data"`
-	AutoRenew      types.Bool   `tfsdk:"auto_renew"`
+	Name            types.String `tfsdk:"name"`
+	Zone            types.String `tfsdk:"zone"`
+	InstanceType    types.String `tfsdk:"instance_type"`
+	SecurityGroup   types.String `tfsdk:"security_group"`
+	SystemImage     types.String `tfsdk:"system_image"`
+	VPCID           types.String `tfsdk:"vpc_id"`
+	SubnetID        types.String `tfsdk:"subnet_id"`
+	PrivateIP       types.String `tfsdk:"private_ip_address"`
+	SystemDiskGiB   types.Int64  `tfsdk:"system_disk_size_gib"`
+	SharedBWPKGID   types.String `tfsdk:"shared_bandwidth_package_id"`
+	ElasticIPID     types.String `tfsdk:"elastic_ip_id"`
+	BandwidthLimit  types.Int64  `tfsdk:"bandwidth_limit_mbps"`
+	ChargeType      types.String `tfsdk:"charge_type"`
+	RootPassword    types.String `tfsdk:"root_login_password"`
+	TotalTransfer   types.Int64  `tfsdk:"total_transfer_kb"`
+	ProjectID       types.Int64  `tfsdk:"project_id"`
+	PeriodMonths    types.Int64  `tfsdk:"period_months"`
+	CloudInitData   types.String `tfsdk:"cloud_init_data"`
+	AutoRenew       types.Bool   `tfsdk:"auto_renew"`
+	PowerState      types.String `tfsdk:"power_state"`
+	RebuildStrategy types.String `tfsdk:"rebuild_strategy"`
 
 	InstanceID        types.String `tfsdk:"instance_id"`
 	InstanceState     types.String `tfsdk:"instance_state"`
+	RenewFlag         types.String `tfsdk:"renew_flag"`
 	CPU               types.Int64  `tfsdk:"cpu"`
 	MemoryGiB         types.Int64  `tfsdk:"memory_gib"`
 	PrivateIPs        types.List   `tfsdk:"private_ips"`
@@ -66,6 +108,8 @@ type TencentCloudVirtualMachineResourceModel struct {
 	RemainingTransfer types.Int64  `tfsdk:"remaining_transfer_kb"`
 	Password          types.String `tfsdk:"password"`
 	DefaultLoginUser  types.String `tfsdk:"default_login_user"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 func (r *TencentCloudVirtualMachineResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -74,14 +118,43 @@ func (r *TencentCloudVirtualMachineResource) Metadata(ctx context.Context, req r
 
 func (r *TencentCloudVirtualMachineResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	replaceStrings := []planmodifier.String{
-		stringplanmodifier.RequiresReplace(),
+		stringplanmodifier.RequiresReplaceIf(
+			func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+				resp.RequiresReplace = !req.StateValue.IsNull() || !virtualMachineWasImported(ctx, req.Private, &resp.Diagnostics)
+			},
+			"Changing this value requires replacement, unless it was unknown after import.",
+			"Changing this value requires replacement, unless it was unknown after import.",
+		),
+	}
+	// Image and cloud-init changes are applied in place by the reinstall endpoint when requested.
+	rebuildStrings := []planmodifier.String{
+		stringplanmodifier.RequiresReplaceIf(
+			func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+				var strategy types.String
+				resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("rebuild_strategy"), &strategy)...)
+				if strategy.ValueString() == rebuildStrategyReinstall {
+					return
+				}
+				resp.RequiresReplace = !req.StateValue.IsNull() || !virtualMachineWasImported(ctx, req.Private, &resp.Diagnostics)
+			},
+			"Changing this value requires replacement, unless `rebuild_strategy` is `reinstall` or it was unknown after import.",
+			"Changing this value requires replacement, unless `rebuild_strategy` is `reinstall` or it was unknown after import.",
+		),
 	}
 	replaceInt64 := []planmodifier.Int64{
-		int64planmodifier.RequiresReplace(),
+		int64planmodifier.RequiresReplaceIf(
+			func(ctx context.Context, req planmodifier.Int64Request, resp *int64planmodifier.RequiresReplaceIfFuncResponse) {
+				resp.RequiresReplace = !req.StateValue.IsNull() || !virtualMachineWasImported(ctx, req.Private, &resp.Diagnostics)
+			},
+			"Changing this value requires replacement, unless it was unknown after import.",
+			"Changing this value requires replacement, unless it was unknown after import.",
+		),
 	}
 
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "Manage Tencent Cloud CVM instances via the Penguin service.",
+		// Version 1 adds the rebuild_strategy default to existing state, see UpgradeState.
+		Version:             1,
+		MarkdownDescription: "Manage Tencent Cloud CVM instances via the Penguin service. Creation waits until the instance is `RUNNING` and has a public IP.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				Computed: true,
@@ -108,7 +181,7 @@ func (r *TencentCloudVirtualMachineResource) Schema(ctx context.Context, req res
 			},
 			"system_image": schema.StringAttribute{
 				Required:      true,
-				PlanModifiers: replaceStrings,
+				PlanModifiers: rebuildStrings,
 			},
 			"vpc_id": schema.StringAttribute{
 				Required:      true,
@@ -119,8 +192,11 @@ func (r *TencentCloudVirtualMachineResource) Schema(ctx context.Context, req res
 				PlanModifiers: replaceStrings,
 			},
 			"private_ip_address": schema.StringAttribute{
-				Optional:      true,
-				PlanModifiers: replaceStrings,
+				Optional: true,
+				Computed: true,
+				PlanModifiers: append([]planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				}, replaceStrings...),
 			},
 			"system_disk_size_gib": schema.Int64Attribute{
 				Required:      true,
@@ -155,16 +231,27 @@ func (r *TencentCloudVirtualMachineResource) Schema(ctx context.Context, req res
 				PlanModifiers: replaceInt64,
 			},
 			"period_months": schema.Int64Attribute{
-				Optional:      true,
-				PlanModifiers: replaceInt64,
+				MarkdownDescription: "Prepaid term in months for the initial purchase and for subsequent automatic renewals. Penguin only changes it as part of a paid renewal, so later changes are recorded in state with a warning but not sent to Tencent Cloud. Ignored for `POSTPAID_BY_HOUR`.",
+				Optional:            true,
 			},
 			"cloud_init_data": schema.StringAttribute{
 				Optional:      true,
 				Sensitive:     true,
-				PlanModifiers: replaceStrings,
+				PlanModifiers: rebuildStrings,
 			},
 			"auto_renew": schema.BoolAttribute{
-				Optional: true,
+				MarkdownDescription: "Enable Tencent Cloud automatic renewal at creation. Penguin only changes the renewal flag as part of a paid renewal (`POST /tencentcloud/vms/:id/renew`), so later changes are recorded in state with a warning but not sent to Tencent Cloud; the flag in effect is reported through `renew_flag`. Ignored for `POSTPAID_BY_HOUR`.",
+				Optional:            true,
+			},
+			"rebuild_strategy": schema.StringAttribute{
+				MarkdownDescription: "How changes to `system_image` or `cloud_init_data` are applied: `replace` (default) destroys and recreates the instance, `reinstall` reinstalls the OS in place via `POST /tencentcloud/vms/:id/reinstall`, keeping the UUID, IP addresses and prepaid term.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(rebuildStrategyReplace),
+			},
+			"power_state": schema.StringAttribute{
+				MarkdownDescription: "Desired power state, `running` or `stopped`. When omitted the power state is not managed. Starting an instance whose transfer quota is exceeded fails with a `409 Conflict`.",
+				Optional:            true,
 			},
 
 			"instance_id": schema.StringAttribute{
@@ -173,6 +260,10 @@ func (r *TencentCloudVirtualMachineResource) Schema(ctx context.Context, req res
 			"instance_state": schema.StringAttribute{
 				Computed: true,
 			},
+			"renew_flag": schema.StringAttribute{
+				MarkdownDescription: "Tencent Cloud renewal flag, e.g. `NOTIFY_AND_AUTO_RENEW`. Only reported for prepaid instances.",
+				Computed:            true,
+			},
 			"cpu": schema.Int64Attribute{
 				Computed: true,
 			},
@@ -213,6 +304,44 @@ func (r *TencentCloudVirtualMachineResource) Schema(ctx context.Context, req res
 				Computed: true,
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+// UpgradeState fills in defaults for attributes that state written by earlier versions lacks.
+// Defaults only apply to planned values, so without this the first plan after an upgrade would
+// show an in-place update of `rebuild_strategy` for every instance.
+func (r *TencentCloudVirtualMachineResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {StateUpgrader: upgradeVirtualMachineStateV0},
+	}
+}
+
+func upgradeVirtualMachineStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	if req.RawState == nil {
+		resp.Diagnostics.AddError("Missing prior state", "The virtual machine state to upgrade is empty.")
+		return
+	}
+	value, err := req.RawState.UnmarshalWithOpts(resp.State.Schema.Type().TerraformType(ctx), tfprotov6.UnmarshalOpts{
+		ValueFromJSONOpts: tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true},
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to upgrade virtual machine state", err.Error())
+		return
+	}
+	resp.State.Raw = value
+
+	var strategy types.String
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("rebuild_strategy"), &strategy)...)
+	if strategy.IsNull() {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("rebuild_strategy"), rebuildStrategyReplace)...)
 	}
 }
 
@@ -220,7 +349,73 @@ func (r *TencentCloudVirtualMachineResource) Configure(ctx context.Context, req
 	configureResourceClient(req, resp, &r.client)
 }
 
+func (r *TencentCloudVirtualMachineResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config TencentCloudVirtualMachineResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateStringOneOf(path.Root("power_state"), config.PowerState, powerStateRunning, powerStateStopped)...)
+	resp.Diagnostics.Append(validateStringOneOf(path.Root("rebuild_strategy"), config.RebuildStrategy, rebuildStrategyReplace, rebuildStrategyReinstall)...)
+
+	if config.ChargeType.ValueString() == chargeTypePostpaidByHour {
+		warnIgnored := func(name string) {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root(name),
+				"Attribute ignored for postpaid instances",
+				fmt.Sprintf("`%s` only applies to prepaid instances and is ignored when `charge_type` is %q.", name, chargeTypePostpaidByHour),
+			)
+		}
+		if !config.AutoRenew.IsNull() {
+			warnIgnored("auto_renew")
+		}
+		if !config.PeriodMonths.IsNull() {
+			warnIgnored("period_months")
+		}
+	}
+}
+
+// ModifyPlan warns about changes to the renewal settings of prepaid instances. The renew endpoint
+// is the only way to change them and it always buys another term, so Update only records them.
+func (r *TencentCloudVirtualMachineResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan TencentCloudVirtualMachineResourceModel
+	var state TencentCloudVirtualMachineResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() || plan.ChargeType.ValueString() == chargeTypePostpaidByHour {
+		return
+	}
+
+	// Values missing from state after import are adopted from config.
+	imported := virtualMachineWasImported(ctx, req.Private, &resp.Diagnostics)
+	for _, setting := range []struct {
+		name             string
+		planned, current attr.Value
+	}{
+		{"auto_renew", plan.AutoRenew, state.AutoRenew},
+		{"period_months", plan.PeriodMonths, state.PeriodMonths},
+	} {
+		name, planned, current := setting.name, setting.planned, setting.current
+		if planned.IsNull() || planned.IsUnknown() || planned.Equal(current) || (imported && current.IsNull()) {
+			continue
+		}
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root(name),
+			"Renewal setting not applied to the instance",
+			fmt.Sprintf("Penguin only applies `%s` as part of a paid renewal (`POST /tencentcloud/vms/:id/renew`), so the new value is saved in state but not sent to Tencent Cloud. "+
+				"`renew_flag` reports the renewal flag actually in effect; replace the instance to apply the change.", name),
+		)
+	}
+}
+
 func (r *TencentCloudVirtualMachineResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine", "Create", &resp.Diagnostics)()
+
 	if r.client == nil {
 		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
 		return
@@ -232,6 +427,14 @@ func (r *TencentCloudVirtualMachineResource) Create(ctx context.Context, req res
 		return
 	}
 
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultVirtualMachineCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
 	if plan.Name.IsUnknown() ||
 		plan.Zone.IsUnknown() ||
 		plan.InstanceType.IsUnknown() ||
@@ -239,7 +442,6 @@ func (r *TencentCloudVirtualMachineResource) Create(ctx context.Context, req res
 		plan.SystemImage.IsUnknown() ||
 		plan.VPCID.IsUnknown() ||
 		plan.SubnetID.IsUnknown() ||
-		plan.PrivateIP.IsUnknown() ||
 		plan.SystemDiskGiB.IsUnknown() ||
 		plan.SharedBWPKGID.IsUnknown() ||
 		plan.ElasticIPID.IsUnknown() ||
@@ -250,7 +452,8 @@ func (r *TencentCloudVirtualMachineResource) Create(ctx context.Context, req res
 		plan.ProjectID.IsUnknown() ||
 		plan.PeriodMonths.IsUnknown() ||
 		plan.CloudInitData.IsUnknown() ||
-		plan.AutoRenew.IsUnknown() {
+		plan.AutoRenew.IsUnknown() ||
+		plan.PowerState.IsUnknown() {
 		resp.Diagnostics.AddError(
 			"Unknown virtual machine configuration",
 			"All input values must be known during planning to create a virtual machine.",
@@ -285,7 +488,8 @@ func (r *TencentCloudVirtualMachineResource) Create(ctx context.Context, req res
 		TotalTransferKB:   plan.TotalTransfer.ValueInt64(),
 	}
 
-	if !plan.PrivateIP.IsNull() {
+	// private_ip_address is computed, so it stays unknown when omitted from config.
+	if !plan.PrivateIP.IsNull() && !plan.PrivateIP.IsUnknown() {
 		v := plan.PrivateIP.ValueString()
 		request.PrivateIPAddress = &v
 	}
@@ -332,16 +536,31 @@ func (r *TencentCloudVirtualMachineResource) Create(ctx context.Context, req res
 		return
 	}
 
-	status, err := r.waitForVirtualMachineStatus(ctx, out.ID, 10*time.Second)
+	state := plan
+	state.ID = types.StringValue(out.ID)
+	setSpanVirtualMachineID(ctx, out.ID)
+
+	// The instance exists and is billed from here on. Record its ID whenever a later step fails
+	// so that Terraform marks it tainted and replaces it, instead of orphaning it.
+	status, err := r.waitForVirtualMachineRunning(ctx, out.ID)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to read virtual machine status after creation", err.Error())
+		resp.Diagnostics.AddError("Failed waiting for virtual machine to start after creation", err.Error())
+		resp.Diagnostics.Append(setPartialState(ctx, &resp.State, &state)...)
 		return
 	}
 
-	state := plan
-	state.ID = types.StringValue(out.ID)
+	if !plan.PowerState.IsNull() {
+		status, err = r.reconcilePowerState(ctx, out.ID, plan.PowerState.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to set virtual machine power state", powerStateErrorDetail(err))
+			resp.Diagnostics.Append(setPartialState(ctx, &resp.State, &state)...)
+			return
+		}
+	}
+
 	resp.Diagnostics.Append(r.applyStatusToState(ctx, status, &state)...)
 	if resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(setPartialState(ctx, &resp.State, &state)...)
 		return
 	}
 
@@ -349,6 +568,8 @@ func (r *TencentCloudVirtualMachineResource) Create(ctx context.Context, req res
 }
 
 func (r *TencentCloudVirtualMachineResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine", "Read", &resp.Diagnostics)()
+
 	if r.client == nil {
 		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
 		return
@@ -360,9 +581,22 @@ func (r *TencentCloudVirtualMachineResource) Read(ctx context.Context, req resou
 		return
 	}
 
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultVirtualMachineReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+	setSpanVirtualMachineID(ctx, state.ID.ValueString())
+	ctx = penguin.WithRegion(ctx, penguin.RegionFromZone(state.Zone.ValueString()))
+
+	// The read that completes an import starts from a state holding only the identifier.
+	importRead := state.InstanceID.IsNull()
+
 	status, err := r.client.GetVirtualMachineStatus(ctx, state.ID.ValueString())
 	if err != nil {
-		if isNotFound(err) {
+		if errors.Is(err, penguin.ErrNotFound) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
@@ -376,9 +610,17 @@ func (r *TencentCloudVirtualMachineResource) Read(ctx context.Context, req resou
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+
+	// With every required input in state, a later refresh has nothing left for the first apply
+	// to reconcile, so changes replace the instance as usual again even if that apply never ran.
+	if !importRead && virtualMachineInputsKnown(state) && virtualMachineWasImported(ctx, req.Private, &resp.Diagnostics) {
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, virtualMachineImportedKey, nil)...)
+	}
 }
 
 func (r *TencentCloudVirtualMachineResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine", "Update", &resp.Diagnostics)()
+
 	if r.client == nil {
 		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
 		return
@@ -392,6 +634,16 @@ func (r *TencentCloudVirtualMachineResource) Update(ctx context.Context, req res
 		return
 	}
 
+	updateTimeout, diags := plan.Timeouts.Update(ctx, defaultVirtualMachineUpdateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+	setSpanVirtualMachineID(ctx, state.ID.ValueString())
+	ctx = penguin.WithRegion(ctx, penguin.RegionFromZone(state.Zone.ValueString()))
+
 	if plan.BandwidthLimit.IsUnknown() {
 		resp.Diagnostics.AddError(
 			"Unknown bandwidth limit",
@@ -400,6 +652,21 @@ func (r *TencentCloudVirtualMachineResource) Update(ctx context.Context, req res
 		return
 	}
 
+	if plan.PowerState.IsUnknown() {
+		resp.Diagnostics.AddError(
+			"Unknown power state",
+			"`power_state` must be known during planning to update the power state.",
+		)
+		return
+	}
+	if plan.SystemImage.IsUnknown() || plan.CloudInitData.IsUnknown() {
+		resp.Diagnostics.AddError(
+			"Unknown system image",
+			"`system_image` and `cloud_init_data` must be known during planning to reinstall the virtual machine.",
+		)
+		return
+	}
+
 	if !plan.BandwidthLimit.IsNull() && (state.BandwidthLimit.IsNull() || plan.BandwidthLimit.ValueInt64() != state.BandwidthLimit.ValueInt64()) {
 		if err := r.client.AdjustVirtualMachineBandwidth(ctx, state.ID.ValueString(), plan.BandwidthLimit.ValueInt64()); err != nil {
 			resp.Diagnostics.AddError("Failed to adjust virtual machine bandwidth", err.Error())
@@ -407,6 +674,25 @@ func (r *TencentCloudVirtualMachineResource) Update(ctx context.Context, req res
 		}
 	}
 
+	// Values missing from state after import are adopted from config rather than applied.
+	imported := virtualMachineWasImported(ctx, req.Private, &resp.Diagnostics)
+
+	imageChanged := !state.SystemImage.IsNull() && !plan.SystemImage.Equal(state.SystemImage)
+	cloudInitChanged := !plan.CloudInitData.Equal(state.CloudInitData) && !(imported && state.CloudInitData.IsNull())
+	if plan.RebuildStrategy.ValueString() == rebuildStrategyReinstall && (imageChanged || cloudInitChanged) {
+		if _, err := r.reinstallVirtualMachine(ctx, state.ID.ValueString(), plan.SystemImage.ValueString(), plan.CloudInitData.ValueStringPointer(), imageChanged); err != nil {
+			resp.Diagnostics.AddError("Failed to reinstall virtual machine", err.Error())
+			return
+		}
+	}
+
+	if !plan.PowerState.IsNull() {
+		if _, err := r.reconcilePowerState(ctx, state.ID.ValueString(), plan.PowerState.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Failed to set virtual machine power state", powerStateErrorDetail(err))
+			return
+		}
+	}
+
 	status, err := r.client.GetVirtualMachineStatus(ctx, state.ID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to read virtual machine status", err.Error())
@@ -421,9 +707,13 @@ func (r *TencentCloudVirtualMachineResource) Update(ctx context.Context, req res
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &newState)...)
+	// The first apply after import reconciles the config, so later changes replace as usual.
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, virtualMachineImportedKey, nil)...)
 }
 
 func (r *TencentCloudVirtualMachineResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine", "Delete", &resp.Diagnostics)()
+
 	if r.client == nil {
 		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
 		return
@@ -435,47 +725,173 @@ func (r *TencentCloudVirtualMachineResource) Delete(ctx context.Context, req res
 		return
 	}
 
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultVirtualMachineDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+	setSpanVirtualMachineID(ctx, state.ID.ValueString())
+	ctx = penguin.WithRegion(ctx, penguin.RegionFromZone(state.Zone.ValueString()))
+
 	if err := r.client.DeleteVirtualMachine(ctx, state.ID.ValueString()); err != nil {
-		if isNotFound(err) {
+		if errors.Is(err, penguin.ErrNotFound) {
 			return
 		}
 		resp.Diagnostics.AddError("Failed to delete virtual machine", err.Error())
 		return
 	}
 
-	err := waitUntil(ctx, 10*time.Second, func(ctx context.Context) (bool, error) {
-		_, err := r.client.GetVirtualMachineStatus(ctx, state.ID.ValueString())
-		if err == nil {
-			return false, nil
-		}
-		if isNotFound(err) {
-			return true, nil
-		}
-		return false, err
-	})
-	if err != nil {
+	if err := waitForVirtualMachineDeleted(ctx, r.client, state.ID.ValueString()); err != nil {
 		resp.Diagnostics.AddError("Failed waiting for virtual machine deletion", err.Error())
 		return
 	}
 }
 
-func (r *TencentCloudVirtualMachineResource) waitForVirtualMachineStatus(ctx context.Context, id string, interval time.Duration) (*penguin.VirtualMachineStatus, error) {
-	var lastStatus *penguin.VirtualMachineStatus
-	err := waitUntil(ctx, interval, func(ctx context.Context) (bool, error) {
-		status, err := r.client.GetVirtualMachineStatus(ctx, id)
-		if err != nil {
-			if isNotFound(err) {
-				return false, nil
+func (r *TencentCloudVirtualMachineResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	id, rest, hasAttributes := strings.Cut(req.ID, ",")
+	id = strings.TrimSpace(id)
+	if id == "" {
+		resp.Diagnostics.AddError(
+			"Unexpected Import Identifier",
+			"Expected the Penguin virtual machine UUID as import identifier (e.g. `7f1f1e97-7f45-4c4b-94b4-2f1c248a8b1e`), optionally followed by "+
+				"`,key=value` pairs for `name`, `security_group`, `vpc_id` and `subnet_id`.",
+		)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+
+	if hasAttributes {
+		for _, pair := range strings.Split(rest, ",") {
+			key, value, ok := strings.Cut(pair, "=")
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if !ok || value == "" || !slices.Contains(virtualMachineImportAttributes, key) {
+				resp.Diagnostics.AddError(
+					"Unexpected Import Identifier",
+					fmt.Sprintf("Invalid `%s` in import identifier; expected `key=value` with a key of %q.", strings.TrimSpace(pair), virtualMachineImportAttributes),
+				)
+				return
 			}
-			return false, err
+			resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root(key), value)...)
 		}
-		lastStatus = status
-		return true, nil
-	})
+	}
+
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, virtualMachineImportedKey, []byte("true"))...)
+}
+
+// waitForVirtualMachineRunning waits for a newly created instance to register, boot and receive
+// a public IP, so that dependent resources can connect to it right away.
+func (r *TencentCloudVirtualMachineResource) waitForVirtualMachineRunning(ctx context.Context, id string) (*penguin.VirtualMachineStatus, error) {
+	return waitForVirtualMachineState(ctx, r.client, id, "to be running with a public IP",
+		[]string{penguin.InstanceStatePending, penguin.InstanceStateStarting, penguin.InstanceStateRebooting, waitStateAwaitingPublicIP},
+		[]string{penguin.InstanceStateRunning},
+		func(status *penguin.VirtualMachineStatus) string {
+			if status.InstanceState == penguin.InstanceStateRunning && len(status.PublicIPs) == 0 {
+				return waitStateAwaitingPublicIP
+			}
+			return status.InstanceState
+		},
+	)
+}
+
+// reconcilePowerState waits for any in-flight transition to settle, then starts or shuts down
+// the instance so that its instance_state matches the desired power_state.
+func (r *TencentCloudVirtualMachineResource) reconcilePowerState(ctx context.Context, id string, desired string) (*penguin.VirtualMachineStatus, error) {
+	status, err := waitForVirtualMachineState(ctx, r.client, id, "to settle before changing power state", transitionalInstanceStates, settledInstanceStates, nil)
 	if err != nil {
 		return nil, err
 	}
-	return lastStatus, nil
+
+	if instanceStateMatchesPowerState(status.InstanceState, desired) {
+		return status, nil
+	}
+
+	switch desired {
+	case powerStateRunning:
+		err = r.client.StartVirtualMachine(ctx, id)
+	case powerStateStopped:
+		err = r.client.ShutdownVirtualMachine(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The instance may briefly keep reporting its previous state after the request is accepted.
+	pending := append(slices.Clone(transitionalInstanceStates), status.InstanceState)
+	return waitForVirtualMachineState(ctx, r.client, id, "to become "+desired, pending, powerStateInstanceStates(desired), nil)
+}
+
+// reinstallVirtualMachine reinstalls the OS and waits until the reinstall has run and the
+// instance settled again.
+func (r *TencentCloudVirtualMachineResource) reinstallVirtualMachine(ctx context.Context, id string, imageID string, cloudInitData *string, imageChanged bool) (*penguin.VirtualMachineStatus, error) {
+	request := penguin.ReinstallVirtualMachineRequest{
+		ImageID:       imageID,
+		CloudInitData: cloudInitData,
+	}
+	if err := r.client.ReinstallVirtualMachine(ctx, id, request); err != nil {
+		return nil, err
+	}
+
+	pending := append(slices.Clone(transitionalInstanceStates), waitStateAwaitingReinstall)
+	return waitForVirtualMachineState(ctx, r.client, id, "to finish reinstalling "+imageID, pending, settledInstanceStates,
+		reinstallProgress(imageID, imageChanged))
+}
+
+// reinstallProgress maps the statuses polled after a reinstall request. Right after the request
+// the status endpoint still reports the settled instance from before, so the reinstall only
+// counts as started once the instance leaves its settled state or, when the image changes,
+// reports the new image. Until then, and while the old image is reported, the status maps to
+// waitStateAwaitingReinstall.
+func reinstallProgress(imageID string, imageChanged bool) func(*penguin.VirtualMachineStatus) string {
+	started := false
+	return func(status *penguin.VirtualMachineStatus) string {
+		newImage := status.ImageID != nil && *status.ImageID == imageID
+		if !slices.Contains(settledInstanceStates, status.InstanceState) || (imageChanged && newImage) {
+			started = true
+		}
+		if !started || !newImage {
+			return waitStateAwaitingReinstall
+		}
+		return status.InstanceState
+	}
+}
+
+var (
+	transitionalInstanceStates = []string{
+		penguin.InstanceStatePending,
+		penguin.InstanceStateStarting,
+		penguin.InstanceStateStopping,
+		penguin.InstanceStateRebooting,
+	}
+	settledInstanceStates = []string{
+		penguin.InstanceStateRunning,
+		penguin.InstanceStateStopped,
+		penguin.InstanceStateSuspendOverUsage,
+	}
+)
+
+// powerStateInstanceStates lists the instance states that satisfy a power_state.
+func powerStateInstanceStates(powerState string) []string {
+	switch powerState {
+	case powerStateRunning:
+		return []string{penguin.InstanceStateRunning}
+	case powerStateStopped:
+		return []string{penguin.InstanceStateStopped, penguin.InstanceStateSuspendOverUsage}
+	}
+	return nil
+}
+
+func instanceStateMatchesPowerState(state string, powerState string) bool {
+	return slices.Contains(powerStateInstanceStates(powerState), state)
+}
+
+func powerStateErrorDetail(err error) string {
+	if errors.Is(err, penguin.ErrConflict) {
+		return "The Penguin service refused to start the instance because its transfer quota is exceeded; it remains stopped. " +
+			"Raise `total_transfer_kb` or reset the transfer usage before setting `power_state = \"running\"`.\n\n" + err.Error()
+	}
+	return err.Error()
 }
 
 func (r *TencentCloudVirtualMachineResource) applyStatusToState(ctx context.Context, status *penguin.VirtualMachineStatus, state *TencentCloudVirtualMachineResourceModel) diag.Diagnostics {
@@ -486,6 +902,42 @@ func (r *TencentCloudVirtualMachineResource) applyStatusToState(ctx context.Cont
 	publicIPs, listDiags := types.ListValueFrom(ctx, types.StringType, status.PublicIPs)
 	diags.Append(listDiags...)
 
+	// Inputs below are only known to the status endpoint after import; keep configured values otherwise.
+	if state.SystemImage.IsNull() {
+		state.SystemImage = types.StringPointerValue(status.ImageID)
+	}
+	if state.SystemDiskGiB.IsNull() {
+		state.SystemDiskGiB = types.Int64Value(status.SystemDiskSizeGiB)
+	}
+	if state.TotalTransfer.IsNull() {
+		state.TotalTransfer = types.Int64Value(status.TotalTransfer)
+	}
+	if state.PrivateIP.IsNull() || state.PrivateIP.IsUnknown() {
+		state.PrivateIP = types.StringNull()
+		if len(status.PrivateIPs) > 0 {
+			state.PrivateIP = types.StringValue(status.PrivateIPs[0])
+		}
+	}
+
+	// State written by the import has no value yet.
+	if state.RebuildStrategy.IsNull() {
+		state.RebuildStrategy = types.StringValue(rebuildStrategyReplace)
+	}
+
+	// Surface power drift only for settled states, and only when the power state is managed.
+	if !state.PowerState.IsNull() && !state.PowerState.IsUnknown() {
+		switch {
+		case instanceStateMatchesPowerState(status.InstanceState, powerStateRunning):
+			state.PowerState = types.StringValue(powerStateRunning)
+		case instanceStateMatchesPowerState(status.InstanceState, powerStateStopped):
+			state.PowerState = types.StringValue(powerStateStopped)
+		}
+	}
+
+	// auto_renew keeps its configured value; renew_flag reports the flag in effect, and postpaid
+	// instances report none.
+	state.RenewFlag = types.StringPointerValue(status.RenewFlag)
+
 	state.InstanceID = types.StringValue(status.InstanceID)
 	state.Zone = types.StringValue(status.Zone)
 	state.InstanceType = types.StringValue(status.InstanceType)
@@ -505,3 +957,20 @@ func (r *TencentCloudVirtualMachineResource) applyStatusToState(ctx context.Cont
 
 	return diags
 }
+
+// virtualMachineInputsKnown reports whether state holds the required inputs that only config
+// or the import identifier can supply.
+func virtualMachineInputsKnown(state TencentCloudVirtualMachineResourceModel) bool {
+	for _, v := range []types.String{state.Name, state.SecurityGroup, state.VPCID, state.SubnetID} {
+		if v.IsNull() || v.IsUnknown() {
+			return false
+		}
+	}
+	return true
+}
+
+func virtualMachineWasImported(ctx context.Context, private privateStateReader, diags *diag.Diagnostics) bool {
+	value, getDiags := private.GetKey(ctx, virtualMachineImportedKey)
+	diags.Append(getDiags...)
+	return string(value) == "true"
+}
@@ -0,0 +1,560 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
+)
+
+func TestTencentCloudVirtualMachineResource_CreatePersistsIDWhenWaitFails(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/tencentcloud/vms":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"vm-1"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/tencentcloud/vms/vm-1/status":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"status":500,"message":"boom"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := penguin.NewClient(server.URL, "token", "", penguin.ClientOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	r := &TencentCloudVirtualMachineResource{client: client}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	plan := tfsdk.Plan{
+		Schema: schemaResp.Schema,
+		Raw: testPlannedObject(t, ctx, schemaResp.Schema, map[string]tftypes.Value{
+			"name":                 tftypes.NewValue(tftypes.String, "vm"),
+			"zone":                 tftypes.NewValue(tftypes.String, "ap-hongkong-2"),
+			"instance_type":        tftypes.NewValue(tftypes.String, "S5.SMALL1"),
+			"security_group":       tftypes.NewValue(tftypes.String, "sg-1"),
+			"system_image":         tftypes.NewValue(tftypes.String, "img-1"),
+			"vpc_id":               tftypes.NewValue(tftypes.String, "vpc-1"),
+			"subnet_id":            tftypes.NewValue(tftypes.String, "subnet-1"),
+			"system_disk_size_gib": tftypes.NewValue(tftypes.Number, 20),
+			"total_transfer_kb":    tftypes.NewValue(tftypes.Number, 1024),
+			"bandwidth_limit_mbps": tftypes.NewValue(tftypes.Number, 10),
+			"rebuild_strategy":     tftypes.NewValue(tftypes.String, rebuildStrategyReplace),
+		}),
+	}
+	resp := resource.CreateResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+		},
+	}
+
+	r.Create(ctx, resource.CreateRequest{Plan: plan}, &resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected an error diagnostic")
+	}
+	if !resp.State.Raw.IsFullyKnown() {
+		t.Fatal("expected partial state without unknown values")
+	}
+
+	var id, instanceState types.String
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("id"), &id)...)
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("instance_state"), &instanceState)...)
+	if id.ValueString() != "vm-1" {
+		t.Fatalf("expected id vm-1 in state, got %s", id)
+	}
+	if !instanceState.IsNull() {
+		t.Fatalf("expected null instance_state, got %s", instanceState)
+	}
+}
+
+func TestTencentCloudVirtualMachineResource_Import(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/tencentcloud/vms/vm-1/status" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"vm-1","zone":"ap-guangzhou-6","instanceId":"ins-1","instanceType":"S5.SMALL1","instanceState":"RUNNING",` +
+			`"systemDiskSizeGiB":50,"privateIps":["10.0.0.12"],"publicIps":["43.1.2.3"],"imageId":"img-1","totalTransfer":1024,"usedTransfer":0}`))
+	}))
+	defer server.Close()
+
+	const typeName = "penguin_tencentcloud_virtual_machine"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, server.URL)
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	read := func(state *tfprotov6.DynamicValue, private []byte) (map[string]tftypes.Value, *tfprotov6.DynamicValue, []byte) {
+		t.Helper()
+		resp, err := protocol.ReadResource(ctx, &tfprotov6.ReadResourceRequest{TypeName: typeName, CurrentState: state, Private: private})
+		if err != nil || len(resp.Diagnostics) > 0 {
+			t.Fatalf("ReadResource: %v %v", err, resp.Diagnostics)
+		}
+		value, err := resp.NewState.Unmarshal(stateType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var attrs map[string]tftypes.Value
+		if err := value.As(&attrs); err != nil {
+			t.Fatal(err)
+		}
+		return attrs, resp.NewState, resp.Private
+	}
+	importID := func(id string) (*tfprotov6.DynamicValue, []byte) {
+		t.Helper()
+		resp, err := protocol.ImportResourceState(ctx, &tfprotov6.ImportResourceStateRequest{TypeName: typeName, ID: id})
+		if err != nil || len(resp.Diagnostics) > 0 || len(resp.ImportedResources) != 1 {
+			t.Fatalf("ImportResourceState(%q): %v %v", id, err, resp.Diagnostics)
+		}
+		return resp.ImportedResources[0].State, resp.ImportedResources[0].Private
+	}
+	imported := func(private []byte) bool {
+		return strings.Contains(string(private), virtualMachineImportedKey)
+	}
+
+	t.Run("UUID only", func(t *testing.T) {
+		state, private := importID("vm-1")
+		attrs, state, private := read(state, private)
+		for name, want := range map[string]string{"zone": "ap-guangzhou-6", "system_image": "img-1", "private_ip_address": "10.0.0.12"} {
+			if !attrs[name].Equal(tftypes.NewValue(tftypes.String, want)) {
+				t.Fatalf("unexpected %s: %s", name, attrs[name])
+			}
+		}
+		if !attrs["name"].IsNull() {
+			t.Fatalf("expected null name, got %s", attrs["name"])
+		}
+		// Config must still supply name and friends, so the first apply keeps the relaxed replacement.
+		if _, _, private = read(state, private); !imported(private) {
+			t.Fatal("expected the import marker to survive a refresh while inputs are missing")
+		}
+	})
+
+	t.Run("with attributes", func(t *testing.T) {
+		state, private := importID("vm-1, name=web-1,security_group=sg-1,vpc_id=vpc-1,subnet_id=subnet-1")
+		attrs, state, private := read(state, private)
+		for name, want := range map[string]string{"name": "web-1", "security_group": "sg-1", "vpc_id": "vpc-1", "subnet_id": "subnet-1", "zone": "ap-guangzhou-6"} {
+			if !attrs[name].Equal(tftypes.NewValue(tftypes.String, want)) {
+				t.Fatalf("unexpected %s: %s", name, attrs[name])
+			}
+		}
+		if !imported(private) {
+			t.Fatal("expected the import marker after the import read")
+		}
+		// A refresh without an intervening apply ends the import reconciliation.
+		if _, _, private = read(state, private); imported(private) {
+			t.Fatalf("expected the import marker to be cleared, got %s", private)
+		}
+	})
+
+	resp, err := protocol.ImportResourceState(ctx, &tfprotov6.ImportResourceStateRequest{TypeName: typeName, ID: "vm-1,zone=ap-guangzhou-6"})
+	if err != nil || len(resp.Diagnostics) == 0 {
+		t.Fatalf("expected an error for an unsupported import attribute, got %v %v", err, resp.Diagnostics)
+	}
+}
+
+func TestTencentCloudVirtualMachineResource_PlanRenewalSettings(t *testing.T) {
+	const typeName = "penguin_tencentcloud_virtual_machine"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, "http://penguin.invalid")
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	inputs := func(chargeType string, autoRenew bool, periodMonths int) map[string]tftypes.Value {
+		return map[string]tftypes.Value{
+			"name":           tftypes.NewValue(tftypes.String, "web-1"),
+			"zone":           tftypes.NewValue(tftypes.String, "ap-guangzhou-6"),
+			"instance_type":  tftypes.NewValue(tftypes.String, "S5.SMALL1"),
+			"security_group": tftypes.NewValue(tftypes.String, "sg-1"),
+			"system_image":   tftypes.NewValue(tftypes.String, "img-1"),
+			"vpc_id":         tftypes.NewValue(tftypes.String, "vpc-1"),
+			"subnet_id":      tftypes.NewValue(tftypes.String, "subnet-1"),
+			"charge_type":    tftypes.NewValue(tftypes.String, chargeType),
+			"auto_renew":     tftypes.NewValue(tftypes.Bool, autoRenew),
+			"period_months":  tftypes.NewValue(tftypes.Number, periodMonths),
+		}
+	}
+	plan := func(chargeType string, autoRenew bool, periodMonths int) []*tfprotov6.Diagnostic {
+		t.Helper()
+		prior := inputs(chargeType, false, 1)
+		prior["id"] = tftypes.NewValue(tftypes.String, "vm-1")
+		config := testDynamicValue(t, stateType, inputs(chargeType, autoRenew, periodMonths))
+		resp, err := protocol.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+			TypeName:         typeName,
+			PriorState:       testDynamicValue(t, stateType, prior),
+			ProposedNewState: config,
+			Config:           config,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Diagnostics
+	}
+
+	if diags := plan("PREPAID", false, 1); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics for an unchanged plan: %v", diags)
+	}
+	for _, tc := range []struct {
+		name         string
+		autoRenew    bool
+		periodMonths int
+	}{
+		{"auto_renew", true, 1},
+		{"period_months", false, 12},
+	} {
+		diags := plan("PREPAID", tc.autoRenew, tc.periodMonths)
+		if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityWarning ||
+			diags[0].Attribute == nil || !diags[0].Attribute.Equal(tftypes.NewAttributePath().WithAttributeName(tc.name)) {
+			t.Fatalf("expected a single warning on %s, got %v", tc.name, diags)
+		}
+	}
+	if diags := plan("POSTPAID_BY_HOUR", true, 12); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics for a postpaid instance: %v", diags)
+	}
+}
+
+func TestTencentCloudVirtualMachineResource_RenewFlagDrift(t *testing.T) {
+	// Automatic renewal was switched off outside Terraform.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/tencentcloud/vms/vm-1/status" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"vm-1","zone":"ap-guangzhou-6","instanceId":"ins-1","instanceType":"S5.SMALL1","instanceState":"RUNNING",` +
+			`"privateIps":["10.0.0.12"],"publicIps":["43.1.2.3"],"imageId":"img-1","totalTransfer":1024,"usedTransfer":0,` +
+			`"renewFlag":"NOTIFY_AND_MANUAL_RENEW"}`))
+	}))
+	defer server.Close()
+
+	const typeName = "penguin_tencentcloud_virtual_machine"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, server.URL)
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	inputs := map[string]tftypes.Value{
+		"name":             tftypes.NewValue(tftypes.String, "web-1"),
+		"zone":             tftypes.NewValue(tftypes.String, "ap-guangzhou-6"),
+		"instance_type":    tftypes.NewValue(tftypes.String, "S5.SMALL1"),
+		"security_group":   tftypes.NewValue(tftypes.String, "sg-1"),
+		"system_image":     tftypes.NewValue(tftypes.String, "img-1"),
+		"vpc_id":           tftypes.NewValue(tftypes.String, "vpc-1"),
+		"subnet_id":        tftypes.NewValue(tftypes.String, "subnet-1"),
+		"charge_type":      tftypes.NewValue(tftypes.String, "PREPAID"),
+		"auto_renew":       tftypes.NewValue(tftypes.Bool, true),
+		"rebuild_strategy": tftypes.NewValue(tftypes.String, rebuildStrategyReplace),
+	}
+	prior := map[string]tftypes.Value{
+		"id":         tftypes.NewValue(tftypes.String, "vm-1"),
+		"renew_flag": tftypes.NewValue(tftypes.String, "NOTIFY_AND_AUTO_RENEW"),
+	}
+	for name, value := range inputs {
+		prior[name] = value
+	}
+
+	read, err := protocol.ReadResource(ctx, &tfprotov6.ReadResourceRequest{
+		TypeName:     typeName,
+		CurrentState: testDynamicValue(t, stateType, prior),
+	})
+	if err != nil || len(read.Diagnostics) > 0 {
+		t.Fatalf("ReadResource: %v %v", err, read.Diagnostics)
+	}
+	object, err := read.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attrs map[string]tftypes.Value
+	if err := object.As(&attrs); err != nil {
+		t.Fatal(err)
+	}
+	if !attrs["auto_renew"].Equal(tftypes.NewValue(tftypes.Bool, true)) ||
+		!attrs["renew_flag"].Equal(tftypes.NewValue(tftypes.String, "NOTIFY_AND_MANUAL_RENEW")) {
+		t.Fatalf("expected the configured auto_renew and the remote renew_flag, got auto_renew %s, renew_flag %s", attrs["auto_renew"], attrs["renew_flag"])
+	}
+
+	// The unchanged configuration still plans cleanly against the refreshed state.
+	config := testDynamicValue(t, stateType, inputs)
+	plan, err := protocol.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       read.NewState,
+		ProposedNewState: read.NewState,
+		Config:           config,
+	})
+	if err != nil || len(plan.Diagnostics) > 0 {
+		t.Fatalf("PlanResourceChange: %v %v", err, plan.Diagnostics)
+	}
+}
+
+func TestTencentCloudVirtualMachineResource_PowerState(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	instanceState := penguin.InstanceStateRunning
+	lagging := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/tencentcloud/vms/vm-1/shutdown":
+			// The status keeps reporting the running instance once after the shutdown is accepted.
+			instanceState, lagging = penguin.InstanceStateStopped, true
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodGet && r.URL.Path == "/tencentcloud/vms/vm-1/status":
+			reported := instanceState
+			if lagging {
+				reported, lagging = penguin.InstanceStateRunning, false
+			}
+			_, _ = fmt.Fprintf(w, `{"id":"vm-1","zone":"ap-guangzhou-6","instanceId":"ins-1","instanceType":"S5.SMALL1","instanceState":%q,`+
+				`"privateIps":["10.0.0.12"],"publicIps":["43.1.2.3"],"imageId":"img-1","totalTransfer":1024,"usedTransfer":0}`, reported)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	const typeName = "penguin_tencentcloud_virtual_machine"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, server.URL)
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	values := func(powerState string) map[string]tftypes.Value {
+		return map[string]tftypes.Value{
+			"id":               tftypes.NewValue(tftypes.String, "vm-1"),
+			"name":             tftypes.NewValue(tftypes.String, "web-1"),
+			"zone":             tftypes.NewValue(tftypes.String, "ap-guangzhou-6"),
+			"instance_type":    tftypes.NewValue(tftypes.String, "S5.SMALL1"),
+			"security_group":   tftypes.NewValue(tftypes.String, "sg-1"),
+			"system_image":     tftypes.NewValue(tftypes.String, "img-1"),
+			"vpc_id":           tftypes.NewValue(tftypes.String, "vpc-1"),
+			"subnet_id":        tftypes.NewValue(tftypes.String, "subnet-1"),
+			"rebuild_strategy": tftypes.NewValue(tftypes.String, rebuildStrategyReplace),
+			"instance_id":      tftypes.NewValue(tftypes.String, "ins-1"),
+			"power_state":      tftypes.NewValue(tftypes.String, powerState),
+		}
+	}
+	attrsOf := func(value *tfprotov6.DynamicValue) map[string]tftypes.Value {
+		t.Helper()
+		object, err := value.Unmarshal(stateType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var attrs map[string]tftypes.Value
+		if err := object.As(&attrs); err != nil {
+			t.Fatal(err)
+		}
+		return attrs
+	}
+
+	planned := testDynamicValue(t, stateType, values(powerStateStopped))
+	applied, err := protocol.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   testDynamicValue(t, stateType, values(powerStateRunning)),
+		PlannedState: planned,
+		Config:       planned,
+	})
+	if err != nil || len(applied.Diagnostics) > 0 {
+		t.Fatalf("ApplyResourceChange: %v %v", err, applied.Diagnostics)
+	}
+	attrs := attrsOf(applied.NewState)
+	if !attrs["power_state"].Equal(tftypes.NewValue(tftypes.String, powerStateStopped)) ||
+		!attrs["instance_state"].Equal(tftypes.NewValue(tftypes.String, penguin.InstanceStateStopped)) {
+		t.Fatalf("unexpected state after shutdown: power_state %s, instance_state %s", attrs["power_state"], attrs["instance_state"])
+	}
+	mu.Lock()
+	want := "GET /tencentcloud/vms/vm-1/status;POST /tencentcloud/vms/vm-1/shutdown;GET /tencentcloud/vms/vm-1/status;GET /tencentcloud/vms/vm-1/status"
+	got := strings.Join(calls, ";")
+	mu.Unlock()
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("expected a shutdown followed by a wait for the stopped state, got %s", got)
+	}
+
+	// A refresh reports the power state from the instance, so a stop outside Terraform shows up as drift.
+	read, err := protocol.ReadResource(ctx, &tfprotov6.ReadResourceRequest{
+		TypeName:     typeName,
+		CurrentState: testDynamicValue(t, stateType, values(powerStateRunning)),
+	})
+	if err != nil || len(read.Diagnostics) > 0 {
+		t.Fatalf("ReadResource: %v %v", err, read.Diagnostics)
+	}
+	if got := attrsOf(read.NewState)["power_state"]; !got.Equal(tftypes.NewValue(tftypes.String, powerStateStopped)) {
+		t.Fatalf("expected power_state to be read back as stopped, got %s", got)
+	}
+}
+
+func TestTencentCloudVirtualMachineResource_UpgradeStateV0(t *testing.T) {
+	const typeName = "penguin_tencentcloud_virtual_machine"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, "http://penguin.invalid")
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	// State written before rebuild_strategy existed, including an attribute since removed.
+	resp, err := protocol.UpgradeResourceState(ctx, &tfprotov6.UpgradeResourceStateRequest{
+		TypeName: typeName,
+		Version:  0,
+		RawState: &tfprotov6.RawState{JSON: []byte(`{"id":"vm-1","name":"web-1","zone":"ap-guangzhou-6","removed":"x"}`)},
+	})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("UpgradeResourceState: %v %v", err, resp.Diagnostics)
+	}
+	value, err := resp.UpgradedState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attrs map[string]tftypes.Value
+	if err := value.As(&attrs); err != nil {
+		t.Fatal(err)
+	}
+	if !attrs["rebuild_strategy"].Equal(tftypes.NewValue(tftypes.String, rebuildStrategyReplace)) {
+		t.Fatalf("expected rebuild_strategy to default to replace, got %s", attrs["rebuild_strategy"])
+	}
+	if !attrs["name"].Equal(tftypes.NewValue(tftypes.String, "web-1")) {
+		t.Fatalf("expected existing values to be kept, got name %s", attrs["name"])
+	}
+}
+
+func TestReinstallProgress(t *testing.T) {
+	t.Parallel()
+
+	status := func(state string, image string) *penguin.VirtualMachineStatus {
+		return &penguin.VirtualMachineStatus{InstanceState: state, ImageID: &image}
+	}
+
+	// Only cloud_init_data changed: the image already matches, so only the transition through a
+	// non-settled state shows that the reinstall ran.
+	progress := reinstallProgress("img-1", false)
+	for _, step := range []struct {
+		status *penguin.VirtualMachineStatus
+		want   string
+	}{
+		{status(penguin.InstanceStateRunning, "img-1"), waitStateAwaitingReinstall},
+		{status(penguin.InstanceStateStopping, "img-1"), penguin.InstanceStateStopping},
+		{status(penguin.InstanceStateStarting, "img-1"), penguin.InstanceStateStarting},
+		{status(penguin.InstanceStateRunning, "img-1"), penguin.InstanceStateRunning},
+	} {
+		if got := progress(step.status); got != step.want {
+			t.Fatalf("cloud-init only: %s: got %q, want %q", step.status.InstanceState, got, step.want)
+		}
+	}
+
+	progress = reinstallProgress("img-2", true)
+	for _, step := range []struct {
+		status *penguin.VirtualMachineStatus
+		want   string
+	}{
+		{status(penguin.InstanceStateRunning, "img-1"), waitStateAwaitingReinstall},
+		{status(penguin.InstanceStateStarting, "img-1"), waitStateAwaitingReinstall},
+		{status(penguin.InstanceStateRunning, "img-2"), penguin.InstanceStateRunning},
+	} {
+		if got := progress(step.status); got != step.want {
+			t.Fatalf("image change: %s %s: got %q, want %q", step.status.InstanceState, *step.status.ImageID, got, step.want)
+		}
+	}
+}
+
+// testConfiguredProviderServer returns a protocol server whose provider is configured against
+// endpoint, together with its schemas.
+func testConfiguredProviderServer(t *testing.T, endpoint string) (tfprotov6.ProviderServer, *tfprotov6.GetProviderSchemaResponse) {
+	t.Helper()
+	// Keep the developer's credentials file and environment out of the configuration.
+	t.Setenv("HOME", t.TempDir())
+	for _, env := range []string{"PENGUIN_CONFIG_FILE", "PENGUIN_PROFILE", "PENGUIN_ENDPOINT", "PENGUIN_AUTH_TOKEN", "PENGUIN_JWT", "PENGUIN_HTTP_TRACE_FILE"} {
+		t.Setenv(env, "")
+	}
+
+	ctx := context.Background()
+	server, err := providerserver.NewProtocol6WithError(New("test", "test")())()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := testDynamicValue(t, schemas.Provider.ValueType(), map[string]tftypes.Value{
+		"endpoint":   tftypes.NewValue(tftypes.String, endpoint),
+		"auth_token": tftypes.NewValue(tftypes.String, "token"),
+	})
+	resp, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range resp.Diagnostics {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("ConfigureProvider: %s: %s", d.Summary, d.Detail)
+		}
+	}
+	return server, schemas
+}
+
+// testDynamicValue encodes an object of typ whose attributes are null unless set in values.
+func testDynamicValue(t *testing.T, typ tftypes.Type, values map[string]tftypes.Value) *tfprotov6.DynamicValue {
+	t.Helper()
+
+	objectType, ok := typ.(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected type %T", typ)
+	}
+	attrs := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		attrs[name] = tftypes.NewValue(attrType, nil)
+		if v, ok := values[name]; ok {
+			attrs[name] = v
+		}
+	}
+	value, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, attrs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &value
+}
+
+// testPlannedObject builds a planned resource object: computed attributes are unknown, all others
+// null unless set in values.
+func testPlannedObject(t *testing.T, ctx context.Context, s schema.Schema, values map[string]tftypes.Value) tftypes.Value {
+	t.Helper()
+
+	objectType, ok := s.Type().TerraformType(ctx).(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected schema type %T", s.Type().TerraformType(ctx))
+	}
+	attrs := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, typ := range objectType.AttributeTypes {
+		switch {
+		case values[name].Type() != nil:
+			attrs[name] = values[name]
+		case s.Attributes[name] != nil && s.Attributes[name].IsComputed():
+			attrs[name] = tftypes.NewValue(typ, tftypes.UnknownValue)
+		default:
+			attrs[name] = tftypes.NewValue(typ, nil)
+		}
+	}
+	return tftypes.NewValue(objectType, attrs)
+}
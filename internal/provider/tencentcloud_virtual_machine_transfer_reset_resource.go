@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
+)
+
+var _ resource.Resource = &TencentCloudVirtualMachineTransferResetResource{}
+
+func NewTencentCloudVirtualMachineTransferResetResource() resource.Resource {
+	return &TencentCloudVirtualMachineTransferResetResource{}
+}
+
+// TencentCloudVirtualMachineTransferResetResource resets the tracked transfer usage of a VM via
+// `POST /tencentcloud/vms/:id/reset-transfer`. Every (re)creation is one reset, so changing
+// `triggers` (e.g. a billing-cycle string) plans a replacement and therefore another reset.
+type TencentCloudVirtualMachineTransferResetResource struct {
+	client *penguin.Client
+}
+
+type TencentCloudVirtualMachineTransferResetResourceModel struct {
+	ID               types.String `tfsdk:"id"`
+	VirtualMachineID types.String `tfsdk:"virtual_machine_id"`
+	Triggers         types.Map    `tfsdk:"triggers"`
+	StartIfSuspended types.Bool   `tfsdk:"start_if_suspended"`
+	TxTransferKB     types.Int64  `tfsdk:"tx_transfer_kb"`
+	RxTransferKB     types.Int64  `tfsdk:"rx_transfer_kb"`
+	UsedTransferKB   types.Int64  `tfsdk:"used_transfer_kb"`
+	InstanceState    types.String `tfsdk:"instance_state"`
+	ResetAt          types.String `tfsdk:"reset_at"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+func (r *TencentCloudVirtualMachineTransferResetResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tencentcloud_virtual_machine_transfer_reset"
+}
+
+func (r *TencentCloudVirtualMachineTransferResetResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reset the recorded transfer usage of a virtual machine via `POST /tencentcloud/vms/:id/reset-transfer`. The reset happens on create and again whenever `triggers` change; the pre-reset usage is kept in state. Destroying this resource does not restore usage.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"virtual_machine_id": schema.StringAttribute{
+				MarkdownDescription: "Penguin UUID of the virtual machine.",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary map of values that, when changed, reset the transfer usage again.",
+				Optional:            true,
+				ElementType:         types.StringType,
+				PlanModifiers:       []planmodifier.Map{mapplanmodifier.RequiresReplace()},
+			},
+			"start_if_suspended": schema.BoolAttribute{
+				MarkdownDescription: "Start the instance after the reset if Penguin had suspended it for exceeding its quota (`SuspendOverUsage`).",
+				Optional:            true,
+			},
+			"tx_transfer_kb": schema.Int64Attribute{
+				MarkdownDescription: "Upload transfer in KB recorded immediately before the reset.",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+			"rx_transfer_kb": schema.Int64Attribute{
+				MarkdownDescription: "Download transfer in KB recorded immediately before the reset.",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+			"used_transfer_kb": schema.Int64Attribute{
+				MarkdownDescription: "Total transfer in KB recorded immediately before the reset.",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+			"instance_state": schema.StringAttribute{
+				MarkdownDescription: "Instance state observed immediately before the reset.",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"reset_at": schema.StringAttribute{
+				MarkdownDescription: "RFC 3339 timestamp of the reset.",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+			}),
+		},
+	}
+}
+
+func (r *TencentCloudVirtualMachineTransferResetResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	configureResourceClient(req, resp, &r.client)
+}
+
+func (r *TencentCloudVirtualMachineTransferResetResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine_transfer_reset", "Create", &resp.Diagnostics)()
+
+	if r.client == nil {
+		resp.Diagnostics.AddError("Unconfigured provider", "The provider has not been configured.")
+		return
+	}
+
+	var plan TencentCloudVirtualMachineTransferResetResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultVirtualMachineUpdateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	if plan.VirtualMachineID.IsUnknown() || plan.StartIfSuspended.IsUnknown() {
+		resp.Diagnostics.AddError(
+			"Unknown transfer reset configuration",
+			"`virtual_machine_id` and `start_if_suspended` must be known during planning to reset transfer usage.",
+		)
+		return
+	}
+
+	// The snapshot must be the usage right before the reset, not a cached earlier response.
+	id := plan.VirtualMachineID.ValueString()
+	status, err := r.client.GetVirtualMachineStatus(penguin.WithoutCache(ctx), id)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read virtual machine status", err.Error())
+		return
+	}
+
+	if err := r.client.ResetVirtualMachineTransfer(ctx, id); err != nil {
+		resp.Diagnostics.AddError("Failed to reset virtual machine transfer usage", err.Error())
+		return
+	}
+
+	state := plan
+	state.ID = plan.VirtualMachineID
+	state.TxTransferKB = types.Int64PointerValue(status.TxTransfer)
+	state.RxTransferKB = types.Int64PointerValue(status.RxTransfer)
+	state.UsedTransferKB = types.Int64Value(status.UsedTransfer)
+	state.InstanceState = types.StringValue(status.InstanceState)
+	state.ResetAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+
+	// The reset already happened; persist it before the optional start so a failure there
+	// does not cause the next apply to reset again.
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !plan.StartIfSuspended.ValueBool() || status.InstanceState != penguin.InstanceStateSuspendOverUsage {
+		return
+	}
+
+	if err := r.client.StartVirtualMachine(ctx, id); err != nil {
+		resp.Diagnostics.AddWarning("Failed to start virtual machine after transfer reset", powerStateErrorDetail(err))
+		return
+	}
+	_, err = waitForVirtualMachineState(ctx, r.client, id, "to start after transfer reset",
+		[]string{penguin.InstanceStateSuspendOverUsage, penguin.InstanceStateStopped, penguin.InstanceStateStarting},
+		[]string{penguin.InstanceStateRunning},
+		nil,
+	)
+	if err != nil {
+		resp.Diagnostics.AddWarning("Failed waiting for virtual machine to start after transfer reset", err.Error())
+	}
+}
+
+func (r *TencentCloudVirtualMachineTransferResetResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine_transfer_reset", "Read", &resp.Diagnostics)()
+
+	var state TencentCloudVirtualMachineTransferResetResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Snapshot semantics: the pre-reset usage is a historical record; no API call on refresh.
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *TencentCloudVirtualMachineTransferResetResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	defer traceResourceOperation(&ctx, "penguin_tencentcloud_virtual_machine_transfer_reset", "Update", &resp.Diagnostics)()
+
+	var plan TencentCloudVirtualMachineTransferResetResourceModel
+	var state TencentCloudVirtualMachineTransferResetResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only start_if_suspended and timeouts can change in place; the reset snapshot is kept.
+	newState := plan
+	newState.ID = state.ID
+	newState.TxTransferKB = state.TxTransferKB
+	newState.RxTransferKB = state.RxTransferKB
+	newState.UsedTransferKB = state.UsedTransferKB
+	newState.InstanceState = state.InstanceState
+	newState.ResetAt = state.ResetAt
+	resp.Diagnostics.Append(resp.State.Set(ctx, &newState)...)
+}
+
+func (r *TencentCloudVirtualMachineTransferResetResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// No remote object to delete; the reset is only recorded in state.
+}
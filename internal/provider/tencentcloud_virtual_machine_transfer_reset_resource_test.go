@@ -0,0 +1,223 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
+)
+
+func TestTencentCloudVirtualMachineTransferResetResource_Create(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	instanceState := penguin.InstanceStateSuspendOverUsage
+	usedTransfer := 4096
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/tencentcloud/vms/vm-1/reset-transfer":
+			usedTransfer = 0
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == "/tencentcloud/vms/vm-1/start":
+			instanceState = penguin.InstanceStateRunning
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodGet && r.URL.Path == "/tencentcloud/vms/vm-1/status":
+			_, _ = fmt.Fprintf(w, `{"id":"vm-1","zone":"ap-guangzhou-6","instanceId":"ins-1","instanceType":"S5.SMALL1","instanceState":%q,`+
+				`"privateIps":["10.0.0.12"],"publicIps":["43.1.2.3"],"imageId":"img-1","totalTransfer":4096,"usedTransfer":%d,"txTransfer":%d,"rxTransfer":%d}`,
+				instanceState, usedTransfer, usedTransfer/4, usedTransfer-usedTransfer/4)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	const typeName = "penguin_tencentcloud_virtual_machine_transfer_reset"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, server.URL)
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	config := testDynamicValue(t, stateType, map[string]tftypes.Value{
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"start_if_suspended": tftypes.NewValue(tftypes.Bool, true),
+	})
+	planned := testDynamicValue(t, stateType, map[string]tftypes.Value{
+		"id":                 tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"start_if_suspended": tftypes.NewValue(tftypes.Bool, true),
+		"tx_transfer_kb":     tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+		"rx_transfer_kb":     tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+		"used_transfer_kb":   tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+		"instance_state":     tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		"reset_at":           tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+	})
+	noState, err := tfprotov6.NewDynamicValue(stateType, tftypes.NewValue(stateType, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := protocol.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   &noState,
+		PlannedState: planned,
+		Config:       config,
+	})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("ApplyResourceChange: %v %v", err, resp.Diagnostics)
+	}
+
+	mu.Lock()
+	got := strings.Join(calls, ";")
+	mu.Unlock()
+	want := "GET /tencentcloud/vms/vm-1/status;POST /tencentcloud/vms/vm-1/reset-transfer;POST /tencentcloud/vms/vm-1/start;GET /tencentcloud/vms/vm-1/status"
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("expected a reset followed by a start of the suspended instance, got %s", got)
+	}
+
+	state, err := resp.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attrs map[string]tftypes.Value
+	if err := state.As(&attrs); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "vm-1"),
+		"used_transfer_kb": tftypes.NewValue(tftypes.Number, 4096),
+		"tx_transfer_kb":   tftypes.NewValue(tftypes.Number, 1024),
+		"rx_transfer_kb":   tftypes.NewValue(tftypes.Number, 3072),
+		"instance_state":   tftypes.NewValue(tftypes.String, penguin.InstanceStateSuspendOverUsage),
+	} {
+		if !attrs[name].Equal(want) {
+			t.Fatalf("expected %s %s from before the reset, got %s", name, want, attrs[name])
+		}
+	}
+}
+
+func TestTencentCloudVirtualMachineTransferResetResource_PlanTriggers(t *testing.T) {
+	const typeName = "penguin_tencentcloud_virtual_machine_transfer_reset"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, "http://penguin.invalid")
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	triggers := func(cycle string) tftypes.Value {
+		return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+			"cycle": tftypes.NewValue(tftypes.String, cycle),
+		})
+	}
+	// Computed attributes carried over from the first reset, as Terraform core proposes them.
+	computed := map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "vm-1"),
+		"used_transfer_kb": tftypes.NewValue(tftypes.Number, 4096),
+		"instance_state":   tftypes.NewValue(tftypes.String, penguin.InstanceStateRunning),
+		"reset_at":         tftypes.NewValue(tftypes.String, "2026-09-01T00:00:00Z"),
+	}
+	withComputed := func(values map[string]tftypes.Value) map[string]tftypes.Value {
+		merged := map[string]tftypes.Value{}
+		for name, value := range computed {
+			merged[name] = value
+		}
+		for name, value := range values {
+			merged[name] = value
+		}
+		return merged
+	}
+	state := testDynamicValue(t, stateType, withComputed(map[string]tftypes.Value{
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"triggers":           triggers("2026-09"),
+	}))
+
+	plan := func(config map[string]tftypes.Value) *tfprotov6.PlanResourceChangeResponse {
+		t.Helper()
+		resp, err := protocol.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+			TypeName:         typeName,
+			PriorState:       state,
+			ProposedNewState: testDynamicValue(t, stateType, withComputed(config)),
+			Config:           testDynamicValue(t, stateType, config),
+		})
+		if err != nil || len(resp.Diagnostics) > 0 {
+			t.Fatalf("PlanResourceChange: %v %v", err, resp.Diagnostics)
+		}
+		return resp
+	}
+
+	resp := plan(map[string]tftypes.Value{
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"triggers":           triggers("2026-09"),
+		"start_if_suspended": tftypes.NewValue(tftypes.Bool, true),
+	})
+	if len(resp.RequiresReplace) > 0 {
+		t.Fatalf("expected start_if_suspended to change in place, got replace %v", resp.RequiresReplace)
+	}
+
+	resp = plan(map[string]tftypes.Value{
+		"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+		"triggers":           triggers("2026-10"),
+	})
+	if len(resp.RequiresReplace) != 1 || !resp.RequiresReplace[0].Equal(tftypes.NewAttributePath().WithAttributeName("triggers")) {
+		t.Fatalf("expected a replacement through triggers, got %v", resp.RequiresReplace)
+	}
+}
+
+func TestTencentCloudVirtualMachineTransferResetResource_UpdateTimeouts(t *testing.T) {
+	const typeName = "penguin_tencentcloud_virtual_machine_transfer_reset"
+	ctx := context.Background()
+	protocol, schemas := testConfiguredProviderServer(t, "http://penguin.invalid")
+	stateType := schemas.ResourceSchemas[typeName].ValueType()
+
+	objectType, ok := stateType.(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected type %T", stateType)
+	}
+	timeoutsType, ok := objectType.AttributeTypes["timeouts"].(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected timeouts type %T", objectType.AttributeTypes["timeouts"])
+	}
+	values := func(create string) map[string]tftypes.Value {
+		return map[string]tftypes.Value{
+			"id":                 tftypes.NewValue(tftypes.String, "vm-1"),
+			"virtual_machine_id": tftypes.NewValue(tftypes.String, "vm-1"),
+			"used_transfer_kb":   tftypes.NewValue(tftypes.Number, 4096),
+			"instance_state":     tftypes.NewValue(tftypes.String, penguin.InstanceStateRunning),
+			"reset_at":           tftypes.NewValue(tftypes.String, "2026-09-01T00:00:00Z"),
+			"timeouts": tftypes.NewValue(timeoutsType, map[string]tftypes.Value{
+				"create": tftypes.NewValue(tftypes.String, create),
+			}),
+		}
+	}
+
+	planned := testDynamicValue(t, stateType, values("30m"))
+	resp, err := protocol.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   testDynamicValue(t, stateType, values("10m")),
+		PlannedState: planned,
+		Config:       planned,
+	})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("ApplyResourceChange: %v %v", err, resp.Diagnostics)
+	}
+
+	want, err := planned.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := resp.NewState.Unmarshal(stateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want) {
+		t.Fatalf("expected the planned state with the new timeouts, got %s", got)
+	}
+}
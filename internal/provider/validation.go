@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func validateStringOneOf(p path.Path, value types.String, allowed ...string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if value.IsNull() || value.IsUnknown() {
+		return diags
+	}
+
+	for _, v := range allowed {
+		if value.ValueString() == v {
+			return diags
+		}
+	}
+
+	quoted := make([]string, 0, len(allowed))
+	for _, v := range allowed {
+		quoted = append(quoted, fmt.Sprintf("%q", v))
+	}
+	diags.AddAttributeError(
+		p,
+		"Invalid Attribute Value",
+		fmt.Sprintf("`%s` must be one of %s, got %q.", p, strings.Join(quoted, ", "), value.ValueString()),
+	)
+	return diags
+}
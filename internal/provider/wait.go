@@ -5,26 +5,214 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
 	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/indexyz/terraform-provider-penguin/internal/penguin"
+)
+
+// defaultAPITimeout bounds resource operations that only issue API calls and do not poll.
+const defaultAPITimeout = 5 * time.Minute
+
+const (
+	defaultWaitMinInterval = 2 * time.Second
+	defaultWaitMaxInterval = 15 * time.Second
+
+	// defaultWaitNotFoundChecks tolerates the delay between a create call returning and the
+	// status endpoint knowing about the instance.
+	defaultWaitNotFoundChecks = 20
+)
+
+// Pseudo states reported by refresh functions for conditions that have no instance_state of
+// their own.
+const (
+	waitStateDeleted           = "DELETED"
+	waitStateAwaitingPublicIP  = "AWAITING_PUBLIC_IP"
+	waitStateAwaitingReinstall = "AWAITING_REINSTALL"
 )
 
-func waitUntil(ctx context.Context, interval time.Duration, check func(context.Context) (done bool, err error)) error {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// waitTimeoutError is returned by the status pollers when the operation deadline passes.
+type waitTimeoutError struct {
+	ID         string
+	WaitingFor string
+	LastState  string
+	Err        error
+}
+
+func (e *waitTimeoutError) Error() string {
+	if e.LastState == "" {
+		return fmt.Sprintf("timed out waiting for virtual machine %s %s; no instance_state was observed. Increase the `timeouts` values if the operation needs more time", e.ID, e.WaitingFor)
+	}
+	return fmt.Sprintf("timed out waiting for virtual machine %s %s; last observed instance_state %q. Increase the `timeouts` values if the operation needs more time", e.ID, e.WaitingFor, e.LastState)
+}
+
+func (e *waitTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// unexpectedStateError is returned when a refresh reports a state that is neither pending nor
+// a target, e.g. an instance that failed to launch.
+type unexpectedStateError struct {
+	ID         string
+	WaitingFor string
+	State      string
+	Expected   []string
+}
+
+func (e *unexpectedStateError) Error() string {
+	return fmt.Sprintf("unexpected instance_state %q for virtual machine %s while waiting %s; expected one of %q", e.State, e.ID, e.WaitingFor, e.Expected)
+}
+
+// stateRefreshFunc fetches the current object and its state. A nil result with a nil error
+// means the object does not exist (yet).
+type stateRefreshFunc func(ctx context.Context) (result any, state string, err error)
+
+// stateWaiter polls Refresh until it reports one of Target. States in Pending keep waiting;
+// an empty Pending accepts any non-target state. The poll interval starts at MinInterval and
+// doubles with jitter up to MaxInterval. The overall deadline is taken from ctx.
+type stateWaiter struct {
+	ID         string
+	WaitingFor string
+	Pending    []string
+	Target     []string
+	Refresh    stateRefreshFunc
+
+	MinInterval    time.Duration
+	MaxInterval    time.Duration
+	NotFoundChecks int
+}
+
+func (w *stateWaiter) Wait(ctx context.Context) (any, error) {
+	minInterval := w.MinInterval
+	if minInterval <= 0 {
+		minInterval = defaultWaitMinInterval
+	}
+	maxInterval := w.MaxInterval
+	if maxInterval < minInterval {
+		maxInterval = max(defaultWaitMaxInterval, minInterval)
+	}
+
+	ctx = tflog.SetField(ctx, "virtual_machine_id", w.ID)
+	ctx = tflog.SetField(ctx, "waiting_for", w.WaitingFor)
 
-	for {
-		done, err := check(ctx)
+	var lastState string
+	timeout := func(err error) error {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return &waitTimeoutError{ID: w.ID, WaitingFor: w.WaitingFor, LastState: lastState, Err: err}
+		}
+		return err
+	}
+
+	interval := minInterval
+	notFound := 0
+	for attempt := 1; ; attempt++ {
+		result, state, err := w.Refresh(ctx)
 		if err != nil {
-			return err
+			return nil, timeout(err)
 		}
-		if done {
-			return nil
+
+		if result == nil {
+			notFound++
+			if notFound > w.NotFoundChecks {
+				return nil, fmt.Errorf("virtual machine %s not found after %d checks while waiting %s", w.ID, notFound, w.WaitingFor)
+			}
+			tflog.Debug(ctx, "Virtual machine not found yet", map[string]any{"attempt": attempt, "not_found_checks": notFound})
+		} else {
+			notFound = 0
+			lastState = state
+			if slices.Contains(w.Target, state) {
+				tflog.Debug(ctx, "Virtual machine reached target state", map[string]any{"attempt": attempt, "state": state})
+				return result, nil
+			}
+			if len(w.Pending) > 0 && !slices.Contains(w.Pending, state) {
+				return result, &unexpectedStateError{ID: w.ID, WaitingFor: w.WaitingFor, State: state, Expected: w.Target}
+			}
+			tflog.Debug(ctx, "Waiting for virtual machine state", map[string]any{"attempt": attempt, "state": state, "target": w.Target})
+		}
+
+		if err := sleepContext(ctx, jitter(interval)); err != nil {
+			return nil, timeout(err)
 		}
+		interval = min(interval*2, maxInterval)
+	}
+}
+
+// jitter spreads d by ±20% so that concurrent waiters do not poll in lockstep.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	spread := int64(d) / 5
+	return d - time.Duration(spread) + time.Duration(rand.Int63n(2*spread+1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// virtualMachineStateRefresh reads the status endpoint, bypassing the client cache, and reports
+// the state derived by stateOf, or the instance_state when stateOf is nil. A 404 is reported as
+// not found.
+func virtualMachineStateRefresh(client *penguin.Client, id string, stateOf func(*penguin.VirtualMachineStatus) string) stateRefreshFunc {
+	return func(ctx context.Context) (any, string, error) {
+		status, err := client.GetVirtualMachineStatus(penguin.WithoutCache(penguin.WithLowPriority(ctx)), id)
+		if err != nil {
+			if errors.Is(err, penguin.ErrNotFound) {
+				return nil, "", nil
+			}
+			return nil, "", err
+		}
+		if stateOf != nil {
+			return status, stateOf(status), nil
 		}
+		return status, status.InstanceState, nil
+	}
+}
+
+// waitForVirtualMachineState waits until the instance reports one of target. stateOf may map
+// a status to a pseudo state; it defaults to the instance_state.
+func waitForVirtualMachineState(ctx context.Context, client *penguin.Client, id string, waitingFor string, pending []string, target []string, stateOf func(*penguin.VirtualMachineStatus) string) (*penguin.VirtualMachineStatus, error) {
+	waiter := &stateWaiter{
+		ID:         id,
+		WaitingFor: waitingFor,
+		Pending:    pending,
+		Target:     target,
+		Refresh:    virtualMachineStateRefresh(client, id, stateOf),
+
+		NotFoundChecks: defaultWaitNotFoundChecks,
+	}
+	result, err := waiter.Wait(ctx)
+	status, _ := result.(*penguin.VirtualMachineStatus)
+	return status, err
+}
+
+// waitForVirtualMachineDeleted waits until the status endpoint returns 404 for the instance.
+func waitForVirtualMachineDeleted(ctx context.Context, client *penguin.Client, id string) error {
+	refresh := virtualMachineStateRefresh(client, id, nil)
+	waiter := &stateWaiter{
+		ID:         id,
+		WaitingFor: "to be deleted",
+		Target:     []string{waitStateDeleted},
+		Refresh: func(ctx context.Context) (any, string, error) {
+			result, state, err := refresh(ctx)
+			if err == nil && result == nil {
+				return struct{}{}, waitStateDeleted, nil
+			}
+			return result, state, err
+		},
 	}
+	_, err := waiter.Wait(ctx)
+	return err
 }
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStateWaiter_ToleratesNotFoundThenReachesTarget(t *testing.T) {
+	t.Parallel()
+
+	states := []string{"", "", "PENDING", "RUNNING"}
+	calls := 0
+	waiter := &stateWaiter{
+		ID:          "vm-1",
+		WaitingFor:  "to be running",
+		Pending:     []string{"PENDING"},
+		Target:      []string{"RUNNING"},
+		MinInterval: time.Millisecond,
+		Refresh: func(ctx context.Context) (any, string, error) {
+			state := states[calls]
+			calls++
+			if state == "" {
+				return nil, "", nil
+			}
+			return state, state, nil
+		},
+		NotFoundChecks: 2,
+	}
+
+	result, err := waiter.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "RUNNING" || calls != len(states) {
+		t.Fatalf("unexpected result %v after %d calls", result, calls)
+	}
+}
+
+func TestStateWaiter_UnexpectedState(t *testing.T) {
+	t.Parallel()
+
+	waiter := &stateWaiter{
+		ID:          "vm-1",
+		WaitingFor:  "to be running",
+		Pending:     []string{"PENDING"},
+		Target:      []string{"RUNNING"},
+		MinInterval: time.Millisecond,
+		Refresh: func(ctx context.Context) (any, string, error) {
+			return "LAUNCH_FAILED", "LAUNCH_FAILED", nil
+		},
+	}
+
+	_, err := waiter.Wait(context.Background())
+	var stateErr *unexpectedStateError
+	if !errors.As(err, &stateErr) || stateErr.State != "LAUNCH_FAILED" {
+		t.Fatalf("expected unexpectedStateError, got %v", err)
+	}
+}
+
+func TestStateWaiter_TimeoutReportsLastState(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	waiter := &stateWaiter{
+		ID:          "vm-1",
+		WaitingFor:  "to be running",
+		Pending:     []string{"PENDING"},
+		Target:      []string{"RUNNING"},
+		MinInterval: time.Millisecond,
+		MaxInterval: 5 * time.Millisecond,
+		Refresh: func(ctx context.Context) (any, string, error) {
+			return "PENDING", "PENDING", nil
+		},
+	}
+
+	_, err := waiter.Wait(ctx)
+	var timeoutErr *waitTimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.LastState != "PENDING" {
+		t.Fatalf("expected waitTimeoutError with last state, got %v", err)
+	}
+}
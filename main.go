@@ -42,6 +42,11 @@ func main() {
 
 	err := providerserver.Serve(context.Background(), provider.New(version, commit), opts)
 
+	// Export the spans still batched once Terraform stops the provider.
+	if shutdownErr := provider.ShutdownTelemetry(context.Background()); shutdownErr != nil {
+		log.Printf("failed to flush telemetry: %s", shutdownErr)
+	}
+
 	if err != nil {
 		log.Fatal(err.Error())
 	}